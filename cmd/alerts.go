@@ -0,0 +1,118 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+)
+
+var (
+	alertCategories []string
+	alertsAll       bool
+	ackResponse     string
+)
+
+// ackResponses maps the --response values to acknowledgements.
+var ackResponses = map[string]ecobee.AckType{
+	"accept":  ecobee.AckAccept,
+	"decline": ecobee.AckDecline,
+	"snooze":  ecobee.AckSnooze,
+}
+
+// alertsCmd represents the alerts command
+var alertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "List thermostat alerts.",
+	Long: `List the open alerts of the thermostat: maintenance reminders,
+temperature and humidity alerts, equipment faults and messages.  Use
+"alerts ack" to respond to one.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+
+		want := map[ecobee.AlertCategory]bool{}
+		for _, s := range alertCategories {
+			c, err := ecobee.ParseAlertCategory(s)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			want[c] = true
+		}
+
+		c := client()
+		alerts, err := c.GetAlerts(thermostat)
+		if err != nil {
+			log.Fatalf("GetAlerts error: %v", err)
+		}
+		n := 0
+		for _, a := range alerts {
+			if !alertsAll && !a.Open() {
+				continue
+			}
+			if len(want) > 0 && !want[a.Category()] {
+				continue
+			}
+			n++
+			status := ""
+			switch ecobee.AckType(a.Acknowledgement) {
+			case "", ecobee.AckUnacknowledged:
+			case ecobee.AckSnooze:
+				status = " (snoozed)"
+			default:
+				status = fmt.Sprintf(" (%s)", a.Acknowledgement)
+			}
+			fmt.Printf("%s  %s %s  %-11s  %s%s\n", a.AcknowledgeRef, a.Date, a.Time, a.Category(), a.Text, status)
+		}
+		if n == 0 {
+			fmt.Printf("No alerts\n")
+		}
+	},
+}
+
+// alertsAckCmd represents the alerts ack command
+var alertsAckCmd = &cobra.Command{
+	Use:   "ack REF...",
+	Short: "Acknowledge alerts.",
+	Long: `Accept, decline or snooze the alerts with the given acknowledge
+references, as listed by "alerts".`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		ack, ok := ackResponses[ackResponse]
+		if !ok {
+			log.Fatalf("Invalid --response %q, want accept, decline or snooze", ackResponse)
+		}
+
+		c := client()
+		for _, ref := range args {
+			if err := c.AcknowledgeAlert(thermostat, ref, ack); err != nil {
+				log.Fatalf("AcknowledgeAlert error: %v", err)
+			}
+			fmt.Printf("Alert %s: %s\n", ref, ackResponse)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(alertsCmd)
+	alertsCmd.AddCommand(alertsAckCmd)
+
+	alertsCmd.Flags().StringSliceVar(&alertCategories, "type", nil, "only list alerts of these types: reminder, temperature, humidity, equipment, message")
+	alertsCmd.Flags().BoolVar(&alertsAll, "all", false, "include accepted and declined alerts")
+	alertsAckCmd.Flags().StringVar(&ackResponse, "response", "accept", "accept, decline or snooze")
+}
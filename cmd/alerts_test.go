@@ -0,0 +1,72 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+func TestAlerts(t *testing.T) {
+	c := newCLI(t)
+	for _, a := range []ecobee.Alert{
+		{AcknowledgeRef: "filter", NotificationType: "furnaceFilter", Text: "Change the filter"},
+		{AcknowledgeRef: "cold", NotificationType: "lowTemp", Text: "It is cold"},
+		{AcknowledgeRef: "hot", NotificationType: "highTemp", Text: "It is hot"},
+		{AcknowledgeRef: "fault", NotificationType: "hvac", Text: "Check the furnace"},
+	} {
+		a.AlertType = "alert"
+		a.Date, a.Time = "2017-12-01", "07:00:00"
+		c.AddAlert("123", a)
+	}
+
+	for _, tc := range []struct {
+		types string
+		want  string
+	}{
+		{"", "filter  2017-12-01 07:00:00  reminder     Change the filter\n" +
+			"cold  2017-12-01 07:00:00  temperature  It is cold\n" +
+			"hot  2017-12-01 07:00:00  temperature  It is hot\n" +
+			"fault  2017-12-01 07:00:00  equipment    Check the furnace\n"},
+		{"reminder", "filter  2017-12-01 07:00:00  reminder     Change the filter\n"},
+		{"Temperature", "cold  2017-12-01 07:00:00  temperature  It is cold\n" +
+			"hot  2017-12-01 07:00:00  temperature  It is hot\n"},
+		{"equipment,reminder", "filter  2017-12-01 07:00:00  reminder     Change the filter\n" +
+			"fault  2017-12-01 07:00:00  equipment    Check the furnace\n"},
+		{"humidity", "No alerts\n"},
+	} {
+		args := []string{"alerts"}
+		if tc.types != "" {
+			args = append(args, "--type", tc.types)
+		}
+		if got := c.run(t, args...); got != tc.want {
+			t.Errorf("alerts --type %q printed\n%s\nwant\n%s", tc.types, got, tc.want)
+		}
+	}
+
+	if got, want := c.run(t, "alerts", "ack", "filter", "--response", "snooze"), "Alert filter: snooze\n"; got != want {
+		t.Errorf("alerts ack printed %q, want %q", got, want)
+	}
+	if a := c.thermostat(t, "123").Alerts[0]; a.Acknowledgement != "defer" || !a.RemindMeLater {
+		t.Errorf("snoozed alert = %+v, want acknowledgement defer", a)
+	}
+	c.run(t, "alerts", "ack", "cold", "hot")
+
+	want := "filter  2017-12-01 07:00:00  reminder     Change the filter (snoozed)\n" +
+		"fault  2017-12-01 07:00:00  equipment    Check the furnace\n"
+	if got := c.run(t, "alerts"); got != want {
+		t.Errorf("alerts printed\n%s\nwant\n%s", got, want)
+	}
+}
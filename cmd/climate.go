@@ -0,0 +1,221 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+)
+
+var (
+	climateName     string
+	climateHeat     string
+	climateCool     string
+	climateHeatFan  string
+	climateCoolFan  string
+	climateSensors  []string
+	climateFrom     string
+	climateOccupied bool
+)
+
+// climateCmd represents the climate command
+var climateCmd = &cobra.Command{
+	Use:   "climate",
+	Short: "Manage comfort settings.",
+	Long: `List, change, add and remove comfort settings (climates) such as Home,
+Away and Sleep.  Comfort settings are named by name or ref.`,
+}
+
+var climateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List comfort settings.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		t, unit := climateThermostat(c)
+		for _, cl := range t.Program.Climates {
+			var sensors []string
+			for _, s := range cl.Sensors {
+				sensors = append(sensors, s.Name)
+			}
+			fmt.Printf("%s (%s): %s - %s  Fan: %s/%s  Sensors: %s\n",
+				cl.Name, cl.ClimateRef,
+				cl.HeatTemp.Format(unit), cl.CoolTemp.Format(unit),
+				cl.HeatFan, cl.CoolFan,
+				strings.Join(sensors, ", "))
+		}
+	},
+}
+
+var climateSetCmd = &cobra.Command{
+	Use:   "set CLIMATE",
+	Short: "Change a comfort setting.",
+	Long: `Change the name, temperatures, fan modes or participating sensors of a
+comfort setting.  Only the given flags are changed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		t, unit := climateThermostat(c)
+		cl := findClimate(t, args[0])
+		err := c.UpdateClimate(thermostat, cl.ClimateRef, func(cl *ecobee.Climate) error {
+			applyClimateFlags(cmd, t, cl, unit)
+			return nil
+		})
+		if err != nil {
+			glog.Exitf("UpdateClimate error: %v", err)
+		}
+		fmt.Printf("Successfully updated %s\n", args[0])
+	},
+}
+
+var climateAddCmd = &cobra.Command{
+	Use:   "add NAME",
+	Short: "Add a comfort setting.",
+	Long: `Add a comfort setting.  It starts as a copy of --from, if given, and
+otherwise needs --heat and --cool.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		t, unit := climateThermostat(c)
+		cl := ecobee.Climate{HeatFan: "auto", CoolFan: "auto", IsOccupied: true}
+		if climateFrom != "" {
+			cl = *findClimate(t, climateFrom)
+			cl.ClimateRef = ""
+		} else {
+			requiredStringFlag("heat", climateHeat)
+			requiredStringFlag("cool", climateCool)
+		}
+		cl.Name = args[0]
+		applyClimateFlags(cmd, t, &cl, unit)
+		if err := c.AddClimate(thermostat, cl); err != nil {
+			glog.Exitf("AddClimate error: %v", err)
+		}
+		fmt.Printf("Successfully added %s\n", cl.Name)
+	},
+}
+
+var climateRmCmd = &cobra.Command{
+	Use:   "rm CLIMATE",
+	Short: "Remove a comfort setting.",
+	Long:  `Remove a comfort setting.  Comfort settings used by the schedule can't be removed.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		t, _ := climateThermostat(c)
+		cl := findClimate(t, args[0])
+		if err := c.DeleteClimate(thermostat, cl.ClimateRef); err != nil {
+			glog.Exitf("DeleteClimate error: %v", err)
+		}
+		fmt.Printf("Successfully removed %s\n", cl.Name)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(climateCmd)
+	climateCmd.AddCommand(climateListCmd, climateSetCmd, climateAddCmd, climateRmCmd)
+
+	for _, c := range []*cobra.Command{climateSetCmd, climateAddCmd} {
+		c.Flags().StringVar(&climateHeat, "heat", "", "heat temp")
+		c.Flags().StringVar(&climateCool, "cool", "", "cool temp")
+		c.Flags().StringVar(&climateHeatFan, "heat-fan", "", "fan mode when heating: auto or on")
+		c.Flags().StringVar(&climateCoolFan, "cool-fan", "", "fan mode when cooling: auto or on")
+		c.Flags().StringSliceVar(&climateSensors, "sensors", nil, "names of the participating sensors")
+		c.Flags().BoolVar(&climateOccupied, "occupied", true, "whether the comfort setting is for an occupied home")
+	}
+	climateSetCmd.Flags().StringVar(&climateName, "name", "", "new name")
+	climateAddCmd.Flags().StringVar(&climateFrom, "from", "", "comfort setting to copy")
+}
+
+// climateThermostat fetches the thermostat with its program and
+// sensors, and returns it with the unit for temperatures.
+func climateThermostat(c *ecobee.Client) (*ecobee.Thermostat, ecobee.Unit) {
+	t, err := c.GetThermostat(thermostat, unitSelection()...)
+	if err != nil {
+		glog.Exitf("error retrieving thermostat %s: %v", thermostat, err)
+	}
+	return t, displayUnit(t)
+}
+
+// findClimate returns the climate with name or ref name, or exits.
+func findClimate(t *ecobee.Thermostat, name string) *ecobee.Climate {
+	cl := t.Program.FindClimate(name)
+	if cl == nil {
+		var names []string
+		for _, c := range t.Program.Climates {
+			names = append(names, c.Name)
+		}
+		glog.Exitf("Unknown climate %q, want one of %s", name, strings.Join(names, ", "))
+	}
+	return cl
+}
+
+// applyClimateFlags applies the flags of the set and add commands to
+// cl.
+func applyClimateFlags(cmd *cobra.Command, t *ecobee.Thermostat, cl *ecobee.Climate, unit ecobee.Unit) {
+	if climateName != "" {
+		cl.Name = climateName
+	}
+	if climateHeat != "" {
+		cl.HeatTemp = parseTempFlag("heat", climateHeat, unit)
+	}
+	if climateCool != "" {
+		cl.CoolTemp = parseTempFlag("cool", climateCool, unit)
+	}
+	if climateHeatFan != "" {
+		cl.HeatFan = climateHeatFan
+	}
+	if climateCoolFan != "" {
+		cl.CoolFan = climateCoolFan
+	}
+	if cmd.Flags().Changed("occupied") {
+		cl.IsOccupied = climateOccupied
+	}
+	if climateSensors != nil {
+		cl.Sensors = nil
+		for _, name := range climateSensors {
+			s := findSensor(t, name)
+			cs, err := ecobee.ClimateSensor(*s)
+			if err != nil {
+				glog.Exitf("%v", err)
+			}
+			cl.Sensors = append(cl.Sensors, cs)
+		}
+	}
+}
+
+// findSensor returns the remote sensor named name, or exits.
+func findSensor(t *ecobee.Thermostat, name string) *ecobee.RemoteSensor {
+	var names []string
+	for i := range t.RemoteSensors {
+		s := &t.RemoteSensors[i]
+		if strings.EqualFold(s.Name, name) {
+			return s
+		}
+		names = append(names, s.Name)
+	}
+	glog.Exitf("Unknown sensor %q, want one of %s", name, strings.Join(names, ", "))
+	return nil
+}
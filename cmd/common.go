@@ -14,7 +14,12 @@
 package cmd
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/golang/glog"
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/viper"
 )
 
 func requiredStringFlag(name string, value string) {
@@ -29,6 +34,42 @@ func checkRequiredFlags() {
 	requiredStringFlag("appid", appID)
 }
 
+// configuredUnit returns the temperature unit from the --units flag or
+// config, and whether one was set.
+func configuredUnit() (ecobee.Unit, bool) {
+	u := viper.GetString("units")
+	if u == "" {
+		return ecobee.Fahrenheit, false
+	}
+	unit, err := ecobee.ParseUnit(u)
+	if err != nil {
+		glog.Exitf("Invalid --units: %v", err)
+	}
+	return unit, true
+}
+
+// unitSelection returns the SelectionOptions needed for displayUnit to
+// work on the fetched thermostat.
+func unitSelection() []ecobee.SelectionOption {
+	if _, ok := configuredUnit(); ok {
+		return nil
+	}
+	return []ecobee.SelectionOption{ecobee.WithIncludeSettings(true)}
+}
+
+// displayUnit returns the temperature unit for input and output: the
+// configured one, or else the thermostat's own display setting.  t
+// must have been fetched with unitSelection.
+func displayUnit(t *ecobee.Thermostat) ecobee.Unit {
+	if u, ok := configuredUnit(); ok {
+		return u
+	}
+	if t.Settings.UseCelsius {
+		return ecobee.Celsius
+	}
+	return ecobee.Fahrenheit
+}
+
 func stringBoolToFloat(b string) float64 {
 	if b == "true" {
 		return 1
@@ -42,3 +83,22 @@ func boolToFloat(b bool) float64 {
 	}
 	return 0
 }
+
+// localTimeLayouts are the layouts accepted by parseLocalTime.
+var localTimeLayouts = []string{
+	"2006-01-02 15:04",
+	ecobee.DateTimeLayout,
+	"2006-01-02T15:04",
+	ecobee.DateLayout,
+}
+
+// parseLocalTime parses a date and time such as "2017-12-24 15:00" in
+// the thermostat's time zone loc.  A date alone is midnight.
+func parseLocalTime(s string, loc *time.Location) (time.Time, error) {
+	for _, l := range localTimeLayouts {
+		if t, err := time.ParseInLocation(l, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, want YYYY-MM-DD [HH:MM]", s)
+}
@@ -0,0 +1,151 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/rspier/go-ecobee/ecobee/ecobeetest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// testThermostat returns a thermostat in Toronto running the "home"
+// climate all week.
+func testThermostat(id, name string) ecobee.Thermostat {
+	schedule := make([][]string, 7)
+	for d := range schedule {
+		for i := 0; i < 48; i++ {
+			schedule[d] = append(schedule[d], "home")
+		}
+	}
+	return ecobee.Thermostat{
+		Identifier: id,
+		Name:       name,
+		Location:   ecobee.Location{TimeZone: "America/Toronto"},
+		Settings:   ecobee.Settings{HvacMode: ecobee.HvacModeHeat},
+		Runtime: ecobee.Runtime{
+			ActualTemperature: 712,
+			DesiredFanMode:    "auto",
+		},
+		Program: ecobee.Program{
+			Schedule: schedule,
+			Climates: []ecobee.Climate{
+				{Name: "Home", ClimateRef: "home", HeatTemp: 680, CoolTemp: 750},
+				{Name: "Away", ClimateRef: "away", HeatTemp: 620, CoolTemp: 800},
+			},
+			CurrentClimateRef: "home",
+		},
+	}
+}
+
+// cli runs commands against a fake ecobee API.
+type cli struct {
+	*ecobeetest.Server
+	config, authCache string
+}
+
+// newCLI starts a fake with thermostats ths, by default thermostat 123,
+// and authorizes the command line against it.  Thermostat 123 is the
+// configured default.
+func newCLI(t *testing.T, ths ...ecobee.Thermostat) *cli {
+	t.Helper()
+	s := ecobeetest.NewServer()
+	t.Cleanup(s.Close)
+	if len(ths) == 0 {
+		ths = append(ths, testThermostat("123", "Home"))
+	}
+	for _, th := range ths {
+		s.AddThermostat(th)
+	}
+
+	dir := t.TempDir()
+	c := &cli{
+		Server:    s,
+		config:    filepath.Join(dir, "config.yaml"),
+		authCache: filepath.Join(dir, "authcache"),
+	}
+	if err := os.WriteFile(c.config, []byte("thermostat: \"123\"\nappid: ecobeetest\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.NewClient(c.authCache); err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+// run runs the command line args and returns what it printed.
+func (c *cli) run(t *testing.T, args ...string) string {
+	t.Helper()
+	resetFlags(RootCmd)
+	// The global flags go first, ahead of any "--".
+	RootCmd.SetArgs(append([]string{args[0], "--config", c.config, "--authcache", c.authCache, "--apiurl", c.URL}, args[1:]...))
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	err = RootCmd.Execute()
+	os.Stdout = stdout
+	w.Close()
+	s := <-out
+	if err != nil {
+		t.Fatalf("%s: %v", strings.Join(args, " "), err)
+	}
+	return s
+}
+
+// resetFlags restores the flags of cmd and its subcommands to their
+// defaults, as the flag variables outlive a single run.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			var def []string
+			if d := strings.Trim(f.DefValue, "[]"); d != "" {
+				def = strings.Split(d, ",")
+			}
+			sv.Replace(def)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
+// thermostat returns the fake's state of thermostat id.
+func (c *cli) thermostat(t *testing.T, id string) ecobee.Thermostat {
+	t.Helper()
+	th, ok := c.Thermostat(id)
+	if !ok {
+		t.Fatalf("no thermostat %s", id)
+	}
+	return th
+}
@@ -29,17 +29,21 @@ var (
 var fanCmd = &cobra.Command{
 	Use:   "fan",
 	Short: "Run the fan.",
-	Long:  `Run the fan for a specified time period.`,
+	Long: `Run the fan for a specified time period.
+
+Like hold, the fan can instead run --until a time, --until-next program change,
+or --indefinite(ly), and --start later.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		checkRequiredFlags()
 		c := client()
 
 		// should get current temperatures and use that for the fan temp
-		err := c.RunFan(thermostat, fanDuration)
+		o, desc := holdOptions(cmd, c, fanDuration)
+		err := c.RunFanWith(thermostat, o)
 		if err != nil {
 			log.Fatalf("RunFan error: %v", err)
 		}
-		fmt.Printf("Running fan for %s\n", fanDuration.String())
+		fmt.Printf("Running fan %s\n", desc)
 
 	},
 }
@@ -57,4 +61,5 @@ func init() {
 	// is called directly, e.g.:
 	// fanCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	fanCmd.Flags().DurationVarP(&fanDuration, "duration", "", 1*time.Hour, "duration")
+	addHoldFlags(fanCmd)
 }
@@ -0,0 +1,51 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFan(t *testing.T) {
+	c := newCLI(t)
+
+	out := c.run(t, "fan", "--duration", "15m")
+
+	if want := "Running fan for 15m0s"; !strings.Contains(out, want) {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	th := c.thermostat(t, "123")
+	if len(th.Events) != 1 || th.Events[0].Fan != "on" || th.Events[0].IsTemperatureAbsolute {
+		t.Errorf("events = %+v, want a fan hold", th.Events)
+	}
+	if th.Runtime.DesiredFanMode != "on" {
+		t.Errorf("desiredFanMode = %q, want on", th.Runtime.DesiredFanMode)
+	}
+}
+
+func TestResume(t *testing.T) {
+	c := newCLI(t)
+	c.run(t, "fan")
+
+	out := c.run(t, "resume")
+
+	if want := "Successfully resumed program"; !strings.Contains(out, want) {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	th := c.thermostat(t, "123")
+	if len(th.Events) != 0 || th.Runtime.DesiredFanMode != "auto" {
+		t.Errorf("events = %+v, fan %q, want no events and fan auto", th.Events, th.Runtime.DesiredFanMode)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+)
+
+var (
+	groupSyncOn  []string
+	groupSyncOff []string
+)
+
+// groupCmd represents the group command
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Manage thermostat groups.",
+	Long: `List, create and delete groups of thermostats, change their members, and
+choose which settings ecobee synchronizes across each group.  Groups are named
+by name or ref.`,
+}
+
+var groupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List thermostat groups.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		requiredStringFlag("appid", appID)
+		gs, err := client().GetGroups()
+		if err != nil {
+			glog.Exitf("GetGroups error: %v", err)
+		}
+		for _, g := range gs {
+			fmt.Printf("%s (%s): %s  Sync: %s\n", g.GroupName, g.GroupRef,
+				strings.Join(g.Thermostats, ", "), strings.Join(g.Syncs(), ", "))
+		}
+	},
+}
+
+var groupCreateCmd = &cobra.Command{
+	Use:   "create NAME [THERMOSTAT...]",
+	Short: "Create a thermostat group.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateGroups(func(gs []ecobee.Group) []ecobee.Group {
+			if ecobee.FindGroup(gs, args[0]) != nil {
+				glog.Exitf("There already is a group %q", args[0])
+			}
+			g := ecobee.Group{GroupName: args[0], Thermostats: args[1:]}
+			setGroupSyncs(&g)
+			return append(gs, g)
+		})
+		fmt.Printf("Successfully created group %s\n", args[0])
+	},
+}
+
+var groupDeleteCmd = &cobra.Command{
+	Use:   "delete GROUP",
+	Short: "Delete a thermostat group.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateGroups(func(gs []ecobee.Group) []ecobee.Group {
+			ref := findGroup(gs, args[0]).GroupRef
+			var kept []ecobee.Group
+			for _, g := range gs {
+				if g.GroupRef != ref {
+					kept = append(kept, g)
+				}
+			}
+			return kept
+		})
+		fmt.Printf("Successfully deleted group %s\n", args[0])
+	},
+}
+
+var groupAddCmd = &cobra.Command{
+	Use:   "add GROUP THERMOSTAT...",
+	Short: "Add thermostats to a group.",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateGroups(func(gs []ecobee.Group) []ecobee.Group {
+			g := findGroup(gs, args[0])
+			for _, id := range args[1:] {
+				if !g.HasThermostat(id) {
+					g.Thermostats = append(g.Thermostats, id)
+				}
+			}
+			return gs
+		})
+		fmt.Printf("Successfully added %s to group %s\n", strings.Join(args[1:], ", "), args[0])
+	},
+}
+
+var groupRemoveCmd = &cobra.Command{
+	Use:   "remove GROUP THERMOSTAT...",
+	Short: "Remove thermostats from a group.",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateGroups(func(gs []ecobee.Group) []ecobee.Group {
+			g := findGroup(gs, args[0])
+			for _, id := range args[1:] {
+				if !g.HasThermostat(id) {
+					glog.Exitf("Thermostat %s is not in group %s", id, g.GroupName)
+				}
+			}
+			var ts []string
+			for _, id := range g.Thermostats {
+				if !slices.Contains(args[1:], id) {
+					ts = append(ts, id)
+				}
+			}
+			g.Thermostats = ts
+			return gs
+		})
+		fmt.Printf("Successfully removed %s from group %s\n", strings.Join(args[1:], ", "), args[0])
+	},
+}
+
+var groupSyncCmd = &cobra.Command{
+	Use:   "sync GROUP",
+	Short: "Choose what a group synchronizes.",
+	Long: `Turn synchronization of settings across a group on with --on or off with
+--off.  Settings are ` + strings.Join(ecobee.GroupSyncNames(), ", ") + `.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var syncs []string
+		updateGroups(func(gs []ecobee.Group) []ecobee.Group {
+			g := findGroup(gs, args[0])
+			setGroupSyncs(g)
+			syncs = g.Syncs()
+			return gs
+		})
+		fmt.Printf("Group %s synchronizes: %s\n", args[0], strings.Join(syncs, ", "))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(groupCmd)
+	groupCmd.AddCommand(groupListCmd, groupCreateCmd, groupDeleteCmd, groupAddCmd, groupRemoveCmd, groupSyncCmd)
+
+	for _, c := range []*cobra.Command{groupCreateCmd, groupSyncCmd} {
+		c.Flags().StringSliceVar(&groupSyncOn, "on", nil, "settings to synchronize, e.g. schedule,alerts")
+	}
+	groupSyncCmd.Flags().StringSliceVar(&groupSyncOff, "off", nil, "settings to stop synchronizing")
+}
+
+// updateGroups fetches the account's groups, changes them with fn and
+// writes the result back.
+func updateGroups(fn func([]ecobee.Group) []ecobee.Group) {
+	requiredStringFlag("appid", appID)
+	c := client()
+	gs, err := c.GetGroups()
+	if err != nil {
+		glog.Exitf("GetGroups error: %v", err)
+	}
+	if _, err := c.UpdateGroups(fn(gs)); err != nil {
+		glog.Exitf("UpdateGroups error: %v", err)
+	}
+}
+
+// findGroup returns the group with name or ref name, or exits.
+func findGroup(gs []ecobee.Group, name string) *ecobee.Group {
+	g := ecobee.FindGroup(gs, name)
+	if g == nil {
+		var names []string
+		for _, g := range gs {
+			names = append(names, g.GroupName)
+		}
+		glog.Exitf("Unknown group %q, want one of %s", name, strings.Join(names, ", "))
+	}
+	return g
+}
+
+// setGroupSyncs applies --on and --off to g.
+func setGroupSyncs(g *ecobee.Group) {
+	for _, s := range groupSyncOn {
+		if err := g.SetSync(s, true); err != nil {
+			glog.Exitf("%v", err)
+		}
+	}
+	for _, s := range groupSyncOff {
+		if err := g.SetSync(s, false); err != nil {
+			glog.Exitf("%v", err)
+		}
+	}
+}
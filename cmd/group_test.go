@@ -0,0 +1,46 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	c := newCLI(t, testThermostat("123", "Home"), testThermostat("456", "Cottage"), testThermostat("789", "Cabin"))
+
+	c.run(t, "group", "create", "Houses", "123", "456", "--on", "schedule")
+	c.run(t, "group", "create", "Other", "789")
+	if got, want := c.run(t, "group", "list"), "Houses (group1): 123, 456  Sync: schedule\nOther (group2): 789  Sync: \n"; got != want {
+		t.Errorf("group list = %q, want %q", got, want)
+	}
+
+	// Changing membership keeps the group's other members, and the
+	// other groups.
+	c.run(t, "group", "add", "houses", "789", "123")
+	c.run(t, "group", "remove", "group1", "456")
+	out := c.run(t, "group", "sync", "Houses", "--on", "alerts,vacation", "--off", "schedule")
+	if want := "Group Houses synchronizes: alerts, vacation"; !strings.Contains(out, want) {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	if got, want := c.run(t, "group", "list"), "Houses (group1): 123, 789  Sync: alerts, vacation\nOther (group2): 789  Sync: \n"; got != want {
+		t.Errorf("group list = %q, want %q", got, want)
+	}
+
+	c.run(t, "group", "delete", "Other")
+	if got, want := c.run(t, "group", "list"), "Houses (group1): 123, 789  Sync: alerts, vacation\n"; got != want {
+		t.Errorf("group list = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hierarchyRecursive bool
+	hierarchyParent    string
+)
+
+// hierarchyCmd represents the hierarchy command
+var hierarchyCmd = &cobra.Command{
+	Use:   "hierarchy",
+	Short: "Manage the EMS management hierarchy.",
+	Long: `List and change the management sets of an EMS account, the thermostats
+assigned to them, and their users.  Sets are named by paths such as
+"/Toronto/Condos"; the root set is "/".`,
+}
+
+var hierarchySetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Manage management sets.",
+}
+
+var hierarchySetListCmd = &cobra.Command{
+	Use:   "list [PATH]",
+	Short: "List management sets and their thermostats.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sets, err := hierarchyClient().ListSets(pathArg(args), hierarchyRecursive)
+		if err != nil {
+			glog.Exitf("ListSets error: %v", err)
+		}
+		for _, s := range sets {
+			printSet(s, 0)
+		}
+	},
+}
+
+var hierarchySetAddCmd = &cobra.Command{
+	Use:   "add NAME",
+	Short: "Add a management set.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := hierarchyClient().AddSet(args[0], hierarchyParent); err != nil {
+			glog.Exitf("AddSet error: %v", err)
+		}
+		fmt.Printf("Successfully added set %s to %s\n", args[0], hierarchyParent)
+	},
+}
+
+var hierarchySetRmCmd = &cobra.Command{
+	Use:   "rm PATH",
+	Short: "Remove a management set.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := hierarchyClient().RemoveSet(args[0]); err != nil {
+			glog.Exitf("RemoveSet error: %v", err)
+		}
+		fmt.Printf("Successfully removed set %s\n", args[0])
+	},
+}
+
+var hierarchySetMoveCmd = &cobra.Command{
+	Use:   "move PATH TO",
+	Short: "Move a management set under another.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := hierarchyClient().MoveSet(args[0], args[1]); err != nil {
+			glog.Exitf("MoveSet error: %v", err)
+		}
+		fmt.Printf("Successfully moved set %s to %s\n", args[0], args[1])
+	},
+}
+
+var hierarchyThermostatCmd = &cobra.Command{
+	Use:   "thermostat",
+	Short: "Assign thermostats to management sets.",
+}
+
+var hierarchyAssignCmd = &cobra.Command{
+	Use:   "assign PATH THERMOSTAT...",
+	Short: "Assign thermostats to a management set.",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ids := strings.Join(args[1:], ",")
+		if err := hierarchyClient().AssignThermostats(args[0], ids); err != nil {
+			glog.Exitf("AssignThermostats error: %v", err)
+		}
+		fmt.Printf("Successfully assigned %s to %s\n", ids, args[0])
+	},
+}
+
+var hierarchyUnassignCmd = &cobra.Command{
+	Use:   "unassign PATH THERMOSTAT...",
+	Short: "Move thermostats from a management set back to the root set.",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ids := strings.Join(args[1:], ",")
+		if err := hierarchyClient().UnassignThermostats(args[0], ids); err != nil {
+			glog.Exitf("UnassignThermostats error: %v", err)
+		}
+		fmt.Printf("Successfully unassigned %s from %s\n", ids, args[0])
+	},
+}
+
+var hierarchyUserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Show users of management sets.",
+}
+
+var hierarchyUserListCmd = &cobra.Command{
+	Use:   "list [PATH]",
+	Short: "List the users of a management set and their privileges.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		users, privs, err := hierarchyClient().ListUsers(pathArg(args), hierarchyRecursive)
+		if err != nil {
+			glog.Exitf("ListUsers error: %v", err)
+		}
+		for _, u := range users {
+			fmt.Printf("%s: %s %s\n", u.UserName, u.FirstName, u.LastName)
+			for _, p := range privs {
+				if p.UserName == u.UserName {
+					fmt.Printf("  %s: %s\n", p.SetPath, strings.Join(privilegeNames(p), ", "))
+				}
+			}
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(hierarchyCmd)
+	hierarchyCmd.AddCommand(hierarchySetCmd, hierarchyThermostatCmd, hierarchyUserCmd)
+	hierarchySetCmd.AddCommand(hierarchySetListCmd, hierarchySetAddCmd, hierarchySetRmCmd, hierarchySetMoveCmd)
+	hierarchyThermostatCmd.AddCommand(hierarchyAssignCmd, hierarchyUnassignCmd)
+	hierarchyUserCmd.AddCommand(hierarchyUserListCmd)
+
+	for _, c := range []*cobra.Command{hierarchySetListCmd, hierarchyUserListCmd} {
+		c.Flags().BoolVarP(&hierarchyRecursive, "recursive", "r", false, "include all descendant sets")
+	}
+	hierarchySetAddCmd.Flags().StringVar(&hierarchyParent, "parent", "/", "path of the parent set")
+}
+
+// hierarchyClient returns a client for the hierarchy commands, which
+// don't need a thermostat.
+func hierarchyClient() *ecobee.Client {
+	requiredStringFlag("appid", appID)
+	return client()
+}
+
+// pathArg returns the optional PATH argument, defaulting to the root.
+func pathArg(args []string) string {
+	if len(args) == 0 {
+		return "/"
+	}
+	return args[0]
+}
+
+func printSet(s ecobee.HierarchySet, depth int) {
+	line := fmt.Sprintf("%s%s (%s)", strings.Repeat("  ", depth), s.SetPath, s.SetRef)
+	if len(s.Thermostats) > 0 {
+		line += ": " + strings.Join(s.Thermostats, ", ")
+	}
+	fmt.Println(line)
+	for _, c := range s.Children {
+		printSet(c, depth+1)
+	}
+}
+
+// privilegeNames returns the names of the privileges p grants.
+func privilegeNames(p ecobee.HierarchyPrivilege) []string {
+	var names []string
+	for _, x := range []struct {
+		name  string
+		allow bool
+	}{
+		{"hierarchy", p.AllowHierarchy},
+		{"access", p.AllowAccess},
+		{"alerts", p.AllowAlerts},
+		{"events", p.AllowEvents},
+	} {
+		if x.allow {
+			names = append(names, x.name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,67 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+func TestHierarchy(t *testing.T) {
+	c := newCLI(t, testThermostat("123", "Home"), testThermostat("456", "Cottage"))
+
+	c.run(t, "hierarchy", "set", "add", "Toronto")
+	c.run(t, "hierarchy", "set", "add", "Condos", "--parent", "/Toronto")
+	c.run(t, "hierarchy", "set", "add", "Ottawa")
+	c.run(t, "hierarchy", "thermostat", "assign", "/Toronto/Condos", "123", "456")
+	c.run(t, "hierarchy", "set", "move", "/Toronto/Condos", "/Ottawa")
+	c.run(t, "hierarchy", "thermostat", "unassign", "/Ottawa/Condos", "456")
+
+	want := "/ (root): 456\n" +
+		"  /Ottawa (set3)\n" +
+		"    /Ottawa/Condos (set2): 123\n" +
+		"  /Toronto (set1)\n"
+	if got := c.run(t, "hierarchy", "set", "list", "-r"); got != want {
+		t.Errorf("set list -r printed\n%s\nwant\n%s", got, want)
+	}
+	want = "/Ottawa (set3)\n  /Ottawa/Condos (set2): 123\n"
+	if got := c.run(t, "hierarchy", "set", "list", "/Ottawa"); got != want {
+		t.Errorf("set list /Ottawa printed\n%s\nwant\n%s", got, want)
+	}
+
+	c.run(t, "hierarchy", "set", "rm", "/Toronto")
+	want = "/ (root): 456\n  /Ottawa (set3)\n"
+	if got := c.run(t, "hierarchy", "set", "list"); got != want {
+		t.Errorf("set list printed\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHierarchyUserList(t *testing.T) {
+	c := newCLI(t)
+	c.AddHierarchyUser(ecobee.HierarchyUser{UserName: "ann@example.com", FirstName: "Ann", LastName: "Smith"},
+		ecobee.HierarchyPrivilege{SetPath: "/", AllowHierarchy: true, AllowAccess: true},
+		ecobee.HierarchyPrivilege{SetPath: "/Toronto", AllowAlerts: true, AllowEvents: true})
+
+	want := "ann@example.com: Ann Smith\n" +
+		"  /: hierarchy, access\n" +
+		"  /Toronto: alerts, events\n"
+	if got := c.run(t, "hierarchy", "user", "list", "-r"); got != want {
+		t.Errorf("user list -r printed\n%s\nwant\n%s", got, want)
+	}
+	want = "ann@example.com: Ann Smith\n  /Toronto: alerts, events\n"
+	if got := c.run(t, "hierarchy", "user", "list", "/Toronto"); got != want {
+		t.Errorf("user list /Toronto printed\n%s\nwant\n%s", got, want)
+	}
+}
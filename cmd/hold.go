@@ -26,7 +26,8 @@ import (
 )
 
 var (
-	heat, cool float64
+	heat, cool string
+	climate    string
 	duration   time.Duration
 	relativeRe = regexp.MustCompile(`^[+-]\d+$`)
 )
@@ -35,13 +36,46 @@ var (
 var holdCmd = &cobra.Command{
 	Use:   "hold [optional relative temp]",
 	Short: "Program a hold",
-	Long:  `Set a hold status on the thermostat to keep the temperature between the specified heat and cool points.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Set a hold status on the thermostat to keep the temperature between the specified heat and cool points.
+
+Temperatures are in the configured units (--units), or the thermostat's own
+units if none are configured.  A unit suffix overrides this, e.g. --heat 21C.
+
+With --climate, hold at one of the thermostat's comfort settings, e.g. Away,
+instead.
+
+The hold lasts for --duration, or --until a time, --until-next program change,
+or --indefinite(ly).  --start schedules it for later.  Times are in the
+thermostat's time zone.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		checkRequiredFlags()
 		c := client()
 
-		if heat == 0 && cool == 0 && len(args) > 0 {
+		if climate != "" {
+			if heat != "" || cool != "" || len(args) > 0 {
+				glog.Exitf("--climate cannot be combined with temperatures")
+			}
+			o, desc := holdOptions(cmd, c, duration)
+			holdClimate(c, climate, o, desc)
+			return
+		}
+
+		relative := heat == "" && cool == "" && len(args) > 0
+		unit, ok := configuredUnit()
+
+		var t *ecobee.Thermostat
+		if relative || !ok {
+			var err error
+			t, err = c.GetThermostat(thermostat, unitSelection()...)
+			if err != nil {
+				glog.Exitf("error retrieving thermostat %s: %v", thermostat, err)
+			}
+			unit = displayUnit(t)
+		}
+
+		var ht, ct ecobee.Temperature
+		if relative {
 			if !relativeRe.MatchString(args[0]) {
 				glog.Exitf("Invalid relative temperature: %q", args[0])
 			}
@@ -55,19 +89,32 @@ var holdCmd = &cobra.Command{
 				glog.Exitf("Maximum relative temperature 2, got %.0f", rel)
 			}
 
-			t, err := c.GetThermostat(thermostat)
-			if err != nil {
-				glog.Exitf("error retrieving thermostat %s: %v", thermostat, err)
-			}
-
-			heat = rel + float64(t.Runtime.DesiredHeat/10.0)
-			cool = rel + float64(t.Runtime.DesiredCool/10.0)
+			delta := ecobee.FromUnit(rel, unit) - ecobee.FromUnit(0, unit)
+			ht = t.Runtime.DesiredHeat + delta
+			ct = t.Runtime.DesiredCool + delta
+		} else {
+			ht = parseTempFlag("heat", heat, unit)
+			ct = parseTempFlag("cool", cool, unit)
 		}
 
-		setHold(c, heat, cool, duration)
+		o, desc := holdOptions(cmd, c, duration)
+		setHold(c, ht, ct, unit, o, desc)
 	},
 }
 
+// parseTempFlag parses the value of a temperature flag.  An unset flag
+// is 0.
+func parseTempFlag(name, value string, unit ecobee.Unit) ecobee.Temperature {
+	if value == "" {
+		return 0
+	}
+	t, err := ecobee.ParseTemperature(value, unit)
+	if err != nil {
+		glog.Exitf("Invalid --%s: %v", name, err)
+	}
+	return t
+}
+
 func init() {
 	RootCmd.AddCommand(holdCmd)
 
@@ -80,16 +127,26 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// holdCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-	holdCmd.Flags().Float64VarP(&heat, "heat", "", 0, "heat temp")
-	holdCmd.Flags().Float64VarP(&cool, "cool", "", 0, "cool temp")
+	holdCmd.Flags().StringVarP(&heat, "heat", "", "", "heat temp")
+	holdCmd.Flags().StringVarP(&cool, "cool", "", "", "cool temp")
+	holdCmd.Flags().StringVar(&climate, "climate", "", "comfort setting to hold at, e.g. away, home or sleep")
 	holdCmd.Flags().DurationVarP(&duration, "duration", "", 1*time.Hour, "duration")
+	addHoldFlags(holdCmd)
 }
 
-func setHold(c *ecobee.Client, heat, cool float64, duration time.Duration) {
+func setHold(c *ecobee.Client, heat, cool ecobee.Temperature, unit ecobee.Unit, o ecobee.HoldOptions, desc string) {
 
-	err := c.HoldTemp(thermostat, heat, cool, duration)
+	err := c.HoldTemperatureWith(thermostat, heat, cool, o)
 	if err != nil {
 		glog.Exitf("HoldTemp error: %v", err)
 	}
-	fmt.Printf("Successfully held temperature between %0.1f and %0.1f for %v\n", heat, cool, duration)
+	fmt.Printf("Successfully held temperature between %s and %s %s\n", heat.Format(unit), cool.Format(unit), desc)
+}
+
+// holdClimate holds at the climate with ref or name name.
+func holdClimate(c *ecobee.Client, name string, o ecobee.HoldOptions, desc string) {
+	if err := c.HoldClimate(thermostat, name, o); err != nil {
+		glog.Exitf("HoldClimate error: %v", err)
+	}
+	fmt.Printf("Successfully held at %s %s\n", name, desc)
 }
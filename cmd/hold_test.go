@@ -0,0 +1,75 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHold(t *testing.T) {
+	c := newCLI(t)
+
+	out := c.run(t, "hold", "--heat", "70", "--cool", "76", "--duration", "2h")
+
+	if want := "Successfully held temperature between 70.0 and 76.0 for 2h0m0s"; !strings.Contains(out, want) {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	th := c.thermostat(t, "123")
+	if len(th.Events) != 1 {
+		t.Fatalf("events = %+v, want one hold", th.Events)
+	}
+	if e := th.Events[0]; e.Type != "hold" || e.HeatHoldTemp != 700 || e.CoolHoldTemp != 760 {
+		t.Errorf("event = %+v, want a hold at 700-760", e)
+	}
+}
+
+func TestHoldCelsius(t *testing.T) {
+	c := newCLI(t)
+
+	c.run(t, "hold", "--units", "C", "--heat", "20", "--cool", "24.5")
+
+	th := c.thermostat(t, "123")
+	if th.Runtime.DesiredHeat != 680 || th.Runtime.DesiredCool != 761 {
+		t.Errorf("desired = %v-%v, want 680-761", th.Runtime.DesiredHeat, th.Runtime.DesiredCool)
+	}
+}
+
+func TestHoldRelative(t *testing.T) {
+	c := newCLI(t)
+
+	c.run(t, "hold", "--", "+2")
+
+	th := c.thermostat(t, "123")
+	if th.Runtime.DesiredHeat != 700 || th.Runtime.DesiredCool != 770 {
+		t.Errorf("desired = %v-%v, want the home climate's 680-750 raised by 2°F", th.Runtime.DesiredHeat, th.Runtime.DesiredCool)
+	}
+}
+
+func TestHoldClimate(t *testing.T) {
+	c := newCLI(t)
+
+	out := c.run(t, "hold", "--climate", "Away", "--indefinite")
+
+	if want := "Successfully held at Away indefinitely"; !strings.Contains(out, want) {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	th := c.thermostat(t, "123")
+	if len(th.Events) != 1 || th.Events[0].HoldClimateRef != "away" {
+		t.Fatalf("events = %+v, want a hold at away", th.Events)
+	}
+	if th.Runtime.DesiredHeat != 620 || th.Runtime.DesiredCool != 800 {
+		t.Errorf("desired = %v-%v, want 620-800", th.Runtime.DesiredHeat, th.Runtime.DesiredCool)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+)
+
+// Flags shared by the commands that set holds.
+var (
+	holdUntilNext  bool
+	holdIndefinite bool
+	holdUntil      string
+	holdStart      string
+)
+
+// clockLayouts are the time of day layouts accepted by parseWhen.
+var clockLayouts = []string{"15:04", "15:04:05"}
+
+// addHoldFlags adds the flags read by holdOptions to cmd.  cmd must
+// also have a --duration flag.
+func addHoldFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&holdUntilNext, "until-next", false, "hold until the next scheduled program change")
+	cmd.Flags().BoolVar(&holdIndefinite, "indefinite", false, "hold until resumed")
+	cmd.Flags().StringVar(&holdUntil, "until", "", `hold until this time, e.g. "18:30" or "2017-12-24 18:30"`)
+	cmd.Flags().StringVar(&holdStart, "start", "", `start the hold at this time instead of now, e.g. "18:30"`)
+}
+
+// holdOptions returns the hold options selected by the flags added
+// with addHoldFlags, and a description of the hold for messages.  d is
+// the value of --duration.
+func holdOptions(cmd *cobra.Command, c *ecobee.Client, d time.Duration) (ecobee.HoldOptions, string) {
+	n := 0
+	for _, f := range []string{"until-next", "indefinite", "until", "duration"} {
+		if cmd.Flags().Changed(f) {
+			n++
+		}
+	}
+	if n > 1 {
+		glog.Exitf("Only one of --until-next, --indefinite, --until and --duration may be given")
+	}
+
+	var loc *time.Location
+	if holdUntil != "" || holdStart != "" {
+		var err error
+		loc, err = c.ThermostatTimeZone(cmd.Context(), thermostat)
+		if err != nil {
+			glog.Exitf("error determining time zone: %v", err)
+		}
+	}
+
+	o := ecobee.HoldFor(d)
+	desc := fmt.Sprintf("for %v", d)
+	from := time.Now()
+	if holdStart != "" {
+		var err error
+		o.Start, err = parseWhen(holdStart, loc, from)
+		if err != nil {
+			glog.Exitf("Invalid --start: %v", err)
+		}
+		from = o.Start
+	}
+
+	switch {
+	case holdUntilNext:
+		o.Type = ecobee.HoldNextTransition
+		desc = "until the next program change"
+	case holdIndefinite:
+		o.Type = ecobee.HoldIndefinite
+		desc = "indefinitely"
+	case holdUntil != "":
+		var err error
+		o.End, err = parseWhen(holdUntil, loc, from)
+		if err != nil {
+			glog.Exitf("Invalid --until: %v", err)
+		}
+		desc = "until " + o.End.Format("2006-01-02 15:04")
+	}
+	if !o.Start.IsZero() {
+		desc = "from " + o.Start.Format("2006-01-02 15:04") + " " + desc
+	}
+	return o, desc
+}
+
+// parseWhen parses a date and time, or a time of day, in loc.  A time
+// of day is its next occurrence after from.
+func parseWhen(s string, loc *time.Location, from time.Time) (time.Time, error) {
+	for _, l := range clockLayouts {
+		c, err := time.ParseInLocation(l, s, loc)
+		if err != nil {
+			continue
+		}
+		f := from.In(loc)
+		t := time.Date(f.Year(), f.Month(), f.Day(), c.Hour(), c.Minute(), c.Second(), 0, loc)
+		if !t.After(f) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return parseLocalTime(s, loc)
+}
@@ -37,7 +37,7 @@ func init() {
 
 func list(c *ecobee.Client) {
 	s := ecobee.Selection{
-		SelectionType: "registered",
+		SelectionType: ecobee.SelectionTypeRegistered,
 	}
 	ts, err := c.GetThermostats(s)
 	if err != nil {
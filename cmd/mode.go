@@ -0,0 +1,58 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+)
+
+// modeCmd represents the mode command
+var modeCmd = &cobra.Command{
+	Use:   "mode [auto|auxHeatOnly|cool|heat|off]",
+	Short: "Show or change the HVAC mode.",
+	Long: `Without an argument, show the current HVAC mode and the modes the
+thermostat's equipment supports.  With an argument, change the mode.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		if len(args) == 0 {
+			s, err := c.GetSettings(thermostat)
+			if err != nil {
+				log.Fatalf("GetSettings error: %v", err)
+			}
+			fmt.Printf("Mode: %s\n", s.HvacMode)
+			fmt.Printf("Supported: %v\n", s.HvacModes())
+			return
+		}
+
+		mode, err := ecobee.ParseHvacMode(args[0])
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := c.SetHvacMode(thermostat, mode); err != nil {
+			log.Fatalf("SetHvacMode error: %v", err)
+		}
+		fmt.Printf("Successfully set mode to %s\n", mode)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(modeCmd)
+}
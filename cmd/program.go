@@ -0,0 +1,384 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	programOutput string
+	programYes    bool
+	programDryRun bool
+)
+
+// programDoc is the YAML representation of an ecobee.Program.
+type programDoc struct {
+	Units    string           `yaml:"units"`
+	Climates []climateDoc     `yaml:"climates"`
+	Schedule []scheduleDayDoc `yaml:"schedule"`
+}
+
+type climateDoc struct {
+	Name      string  `yaml:"name"`
+	Ref       string  `yaml:"ref"`
+	Heat      float64 `yaml:"heat"`
+	Cool      float64 `yaml:"cool"`
+	HeatFan   string  `yaml:"heatFan,omitempty"`
+	CoolFan   string  `yaml:"coolFan,omitempty"`
+	Occupied  bool    `yaml:"occupied"`
+	Optimized bool    `yaml:"optimized,omitempty"`
+}
+
+// scheduleDayDoc is the schedule of one or more days with the same
+// ranges, e.g. days "mon-fri" and ranges "00:00-06:00 sleep".
+type scheduleDayDoc struct {
+	Days   string   `yaml:"days"`
+	Ranges []string `yaml:"ranges"`
+}
+
+// programCmd represents the program command
+var programCmd = &cobra.Command{
+	Use:   "program",
+	Short: "Export and import programs.",
+	Long: `Export a thermostat's program (schedule and comfort settings) as YAML, and
+import it into other thermostats.`,
+}
+
+var programExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the program as YAML.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		t, err := c.GetThermostat(thermostat, unitSelection()...)
+		if err != nil {
+			glog.Exitf("error retrieving thermostat %s: %v", thermostat, err)
+		}
+		y, err := yaml.Marshal(newProgramDoc(&t.Program, displayUnit(t)))
+		if err != nil {
+			glog.Exitf("error marshaling yaml: %v", err)
+		}
+		if programOutput == "" || programOutput == "-" {
+			os.Stdout.Write(y)
+			return
+		}
+		if err := os.WriteFile(programOutput, y, 0644); err != nil {
+			glog.Exitf("error writing %s: %v", programOutput, err)
+		}
+	},
+}
+
+var programImportCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Import a program from YAML.",
+	Long: `Replace the program of the thermostats given by --thermostat, a comma
+separated list, with the one in FILE as written by "program export".  The
+changes to each thermostat are shown, and must be confirmed unless --yes is
+given.  Comfort settings are matched by ref, or by name when the ref is
+missing or names another comfort setting, as refs such as "smart1" differ
+between thermostats; their fields that are not in the file, such as
+participating sensors, are kept.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+
+		y, err := os.ReadFile(args[0])
+		if err != nil {
+			glog.Exitf("error reading %s: %v", args[0], err)
+		}
+		var doc programDoc
+		if err := yaml.Unmarshal(y, &doc); err != nil {
+			glog.Exitf("error parsing %s: %v", args[0], err)
+		}
+		unit, ok := configuredUnit()
+		if doc.Units != "" {
+			if unit, err = ecobee.ParseUnit(doc.Units); err != nil {
+				glog.Exitf("error parsing %s: %v", args[0], err)
+			}
+		} else if !ok {
+			glog.Exitf("%s has no units, and none are configured", args[0])
+		}
+
+		c := client()
+		for _, id := range strings.Split(thermostat, ",") {
+			old, err := c.GetProgram(id)
+			if err != nil {
+				glog.Exitf("error retrieving program for %s: %v", id, err)
+			}
+			p, err := doc.program(old, unit)
+			if err != nil {
+				glog.Exitf("invalid program in %s: %v", args[0], err)
+			}
+
+			diff := diffLines(programLines(old, unit), programLines(p, unit))
+			if len(diff) == 0 {
+				fmt.Printf("%s: no changes\n", id)
+				continue
+			}
+			fmt.Printf("%s:\n%s", id, strings.Join(diff, ""))
+			if programDryRun || (!programYes && !confirm("Apply to "+id)) {
+				continue
+			}
+			if err := c.UpdateProgram(id, *p); err != nil {
+				glog.Exitf("UpdateProgram error for %s: %v", id, err)
+			}
+			fmt.Printf("%s: updated\n", id)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(programCmd)
+	programCmd.AddCommand(programExportCmd, programImportCmd)
+
+	programExportCmd.Flags().StringVarP(&programOutput, "output", "o", "", "write to this file instead of stdout")
+	programImportCmd.Flags().BoolVarP(&programYes, "yes", "y", false, "apply without asking")
+	programImportCmd.Flags().BoolVar(&programDryRun, "dry-run", false, "only show the changes")
+}
+
+// stdin reads confirmations.  It is shared so that answers read ahead
+// into its buffer aren't lost between questions.
+var stdin = bufio.NewReader(os.Stdin)
+
+// confirm asks a yes/no question on the terminal.
+func confirm(q string) bool {
+	fmt.Printf("%s? [y/N] ", q)
+	a, _ := stdin.ReadString('\n')
+	a = strings.ToLower(strings.TrimSpace(a))
+	return a == "y" || a == "yes"
+}
+
+// round1 rounds f to one decimal place.
+func round1(f float64) float64 {
+	return math.Round(f*10) / 10
+}
+
+// importTemp returns the Temperature for v degrees in unit.  If v is
+// what export wrote for old, old is kept: one decimal can't represent
+// every Temperature in Celsius, so converting back could change a
+// setpoint that wasn't edited.
+func importTemp(v float64, unit ecobee.Unit, old *ecobee.Temperature) ecobee.Temperature {
+	if old != nil && round1(old.In(unit)) == v {
+		return *old
+	}
+	return ecobee.FromUnit(v, unit)
+}
+
+func newProgramDoc(p *ecobee.Program, unit ecobee.Unit) programDoc {
+	doc := programDoc{Units: unit.String()}
+	for _, c := range p.Climates {
+		doc.Climates = append(doc.Climates, climateDoc{
+			Name:      c.Name,
+			Ref:       c.ClimateRef,
+			Heat:      round1(c.HeatTemp.In(unit)),
+			Cool:      round1(c.CoolTemp.In(unit)),
+			HeatFan:   c.HeatFan,
+			CoolFan:   c.CoolFan,
+			Occupied:  c.IsOccupied,
+			Optimized: c.IsOptimized,
+		})
+	}
+
+	// Group runs of days with the same schedule.
+	var prev string
+	for _, d := range ecobee.Weekdays {
+		var ranges []string
+		for _, r := range p.DayRanges(d) {
+			ranges = append(ranges, r.String())
+		}
+		day := strings.ToLower(d.String()[:3])
+		cur := strings.Join(ranges, "\n")
+		if n := len(doc.Schedule); n > 0 && cur == prev {
+			from, _, _ := strings.Cut(doc.Schedule[n-1].Days, "-")
+			doc.Schedule[n-1].Days = from + "-" + day
+			continue
+		}
+		doc.Schedule = append(doc.Schedule, scheduleDayDoc{Days: day, Ranges: ranges})
+		prev = cur
+	}
+	return doc
+}
+
+// program returns the program described by doc.  Climates that exist in
+// old keep the fields doc doesn't describe.
+func (doc *programDoc) program(old *ecobee.Program, unit ecobee.Unit) (*ecobee.Program, error) {
+	matches := doc.matchClimates(old)
+	// refs maps the refs in doc to those in the program.
+	refs := map[string]string{}
+	taken := map[string]bool{}
+	for _, oc := range old.Climates {
+		taken[oc.ClimateRef] = true
+	}
+	p := &ecobee.Program{CurrentClimateRef: old.CurrentClimateRef}
+	for i, cd := range doc.Climates {
+		var c ecobee.Climate
+		var oldHeat, oldCool *ecobee.Temperature
+		if oc := matches[i]; oc != nil {
+			c = *oc
+			oldHeat, oldCool = &oc.HeatTemp, &oc.CoolTemp
+		} else {
+			// A new climate keeps its ref unless that is taken
+			// here, so the schedule can name it.
+			c.ClimateRef = cd.Ref
+			for n := 1; c.ClimateRef == "" || taken[c.ClimateRef]; n++ {
+				c.ClimateRef = fmt.Sprintf("smart%d", n)
+			}
+			taken[c.ClimateRef] = true
+		}
+		if cd.Ref != "" {
+			refs[cd.Ref] = c.ClimateRef
+		}
+		c.Name = cd.Name
+		c.HeatTemp = importTemp(cd.Heat, unit, oldHeat)
+		c.CoolTemp = importTemp(cd.Cool, unit, oldCool)
+		if cd.HeatFan != "" {
+			c.HeatFan = cd.HeatFan
+		}
+		if cd.CoolFan != "" {
+			c.CoolFan = cd.CoolFan
+		}
+		c.IsOccupied = cd.Occupied
+		c.IsOptimized = cd.Optimized
+		if c.CoolTemp < c.HeatTemp {
+			return nil, fmt.Errorf("climate %s: heat %.1f is above cool %.1f", cd.Ref, cd.Heat, cd.Cool)
+		}
+		p.Climates = append(p.Climates, c)
+	}
+
+	for range ecobee.Weekdays {
+		p.Schedule = append(p.Schedule, make([]string, ecobee.SlotsPerDay))
+	}
+	for _, sd := range doc.Schedule {
+		for _, r := range sd.Ranges {
+			if span, climate, ok := strings.Cut(r, " "); ok {
+				if ref, ok := refs[climate]; ok {
+					r = span + " " + ref
+				}
+			}
+			ch, err := p.ParseScheduleChange(sd.Days + " " + r)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", sd.Days, err)
+			}
+			if err := p.SetSchedule(ch); err != nil {
+				return nil, fmt.Errorf("%s: %v", sd.Days, err)
+			}
+		}
+	}
+	for i, day := range p.Schedule {
+		for j, ref := range day {
+			if ref == "" {
+				return nil, fmt.Errorf("%s %s is not scheduled", ecobee.Weekdays[i], ecobee.FormatClock(time.Duration(j)*ecobee.SlotDuration))
+			}
+		}
+	}
+	return p, p.Validate()
+}
+
+// matchClimates returns the climate of old that each climate of doc
+// describes, or nil for new ones.  A climate matches the one with its
+// ref and name, else the one with its name, as a program exported from
+// another thermostat has that thermostat's refs for custom climates.
+// Failing both, it matches the one with its ref, which renames it.
+func (doc *programDoc) matchClimates(old *ecobee.Program) []*ecobee.Climate {
+	matches := make([]*ecobee.Climate, len(doc.Climates))
+	used := map[string]bool{}
+	match := func(i int, ok func(cd climateDoc, oc *ecobee.Climate) bool) {
+		for j := range old.Climates {
+			oc := &old.Climates[j]
+			if matches[i] == nil && !used[oc.ClimateRef] && ok(doc.Climates[i], oc) {
+				matches[i] = oc
+				used[oc.ClimateRef] = true
+			}
+		}
+	}
+	sameRef := func(cd climateDoc, oc *ecobee.Climate) bool { return cd.Ref != "" && cd.Ref == oc.ClimateRef }
+	sameName := func(cd climateDoc, oc *ecobee.Climate) bool { return strings.EqualFold(cd.Name, oc.Name) }
+	for _, ok := range []func(climateDoc, *ecobee.Climate) bool{
+		func(cd climateDoc, oc *ecobee.Climate) bool { return sameRef(cd, oc) && sameName(cd, oc) },
+		sameName,
+		sameRef,
+	} {
+		for i := range doc.Climates {
+			match(i, ok)
+		}
+	}
+	return matches
+}
+
+// programLines renders p as lines for diffing.
+func programLines(p *ecobee.Program, unit ecobee.Unit) []string {
+	var ls []string
+	for _, c := range p.Climates {
+		ls = append(ls, fmt.Sprintf("climate %s (%s): heat %s cool %s fan %s/%s occupied %v optimized %v\n",
+			c.ClimateRef, c.Name, c.HeatTemp.Format(unit), c.CoolTemp.Format(unit),
+			c.HeatFan, c.CoolFan, c.IsOccupied, c.IsOptimized))
+	}
+	for _, d := range ecobee.Weekdays {
+		for _, r := range p.DayRanges(d) {
+			ls = append(ls, fmt.Sprintf("%s %s\n", strings.ToLower(d.String()[:3]), r))
+		}
+	}
+	return ls
+}
+
+// diffLines returns the lines removed from a, prefixed with "-", and
+// added in b, prefixed with "+", in order.  It is empty if a and b are
+// the same.
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var d []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			d = append(d, "- "+a[i])
+			i++
+		default:
+			d = append(d, "+ "+b[j])
+			j++
+		}
+	}
+	return d
+}
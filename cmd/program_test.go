@@ -0,0 +1,223 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+func TestDiffLines(t *testing.T) {
+	for _, tc := range []struct {
+		a, b, want []string
+	}{
+		{nil, nil, nil},
+		{[]string{"x"}, []string{"x"}, nil},
+		{nil, []string{"x"}, []string{"+ x"}},
+		{[]string{"x"}, nil, []string{"- x"}},
+		{[]string{"a", "b", "c"}, []string{"a", "B", "c"}, []string{"- b", "+ B"}},
+		{[]string{"a", "b", "c"}, []string{"b", "c", "d"}, []string{"- a", "+ d"}},
+		{[]string{"a", "b"}, []string{"b", "a"}, []string{"- a", "+ a"}},
+	} {
+		if got := diffLines(tc.a, tc.b); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestProgramRoundTrip(t *testing.T) {
+	// 71.5°F is 21.94°C, exported as 21.9, which is 71.4°F.
+	th := testThermostat("123", "Home")
+	th.Program.Climates[0].HeatTemp = 715
+	c := newCLI(t, th)
+	y := filepath.Join(t.TempDir(), "program.yaml")
+	c.run(t, "program", "export", "--units", "C", "-o", y)
+
+	out := c.run(t, "program", "import", "--units", "C", "--yes", y)
+
+	if want := "123: no changes"; !strings.Contains(out, want) {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	before := c.thermostat(t, "123")
+
+	// Editing one climate leaves the others' setpoints alone.
+	b, err := os.ReadFile(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(b), "heat: 16.7", "heat: 16", 1)
+	if edited == string(b) {
+		t.Fatalf("away climate not found in %s", b)
+	}
+	if err := os.WriteFile(y, []byte(edited), 0666); err != nil {
+		t.Fatal(err)
+	}
+	c.run(t, "program", "import", "--units", "C", "--yes", y)
+
+	after := c.thermostat(t, "123")
+	if after.ThermostatRev == before.ThermostatRev {
+		t.Fatal("program not updated")
+	}
+	if h := after.Program.Climates[0].HeatTemp; h != 715 {
+		t.Errorf("home heat = %v, want 715", h)
+	}
+	if h := after.Program.Climates[1].HeatTemp; h != 608 {
+		t.Errorf("away heat = %v, want 608 (16°C)", h)
+	}
+}
+
+func TestProgramImportConfirm(t *testing.T) {
+	c := newCLI(t, testThermostat("123", "Home"), testThermostat("456", "Cottage"))
+	y := filepath.Join(t.TempDir(), "program.yaml")
+	c.run(t, "program", "export", "-o", y)
+	b, err := os.ReadFile(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(y, []byte(strings.Replace(string(b), "heat: 68", "heat: 67", 1)), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both answers arrive at once, as when piped.
+	saved := stdin
+	stdin = bufio.NewReader(strings.NewReader("n\ny\n"))
+	defer func() { stdin = saved }()
+	out := c.run(t, "program", "import", "--thermostat", "123,456", y)
+
+	if strings.Contains(out, "123: updated") || !strings.Contains(out, "456: updated") {
+		t.Errorf("output = %q, want only 456 updated", out)
+	}
+	if h := c.thermostat(t, "123").Program.Climates[0].HeatTemp; h != 680 {
+		t.Errorf("123 home heat = %v, want 680", h)
+	}
+	if h := c.thermostat(t, "456").Program.Climates[0].HeatTemp; h != 670 {
+		t.Errorf("456 home heat = %v, want 670", h)
+	}
+}
+
+func TestProgramMatchClimates(t *testing.T) {
+	old := &ecobee.Program{Climates: []ecobee.Climate{
+		{Name: "Home", ClimateRef: "home", Colour: 1},
+		{Name: "Away", ClimateRef: "away", Colour: 2},
+		{Name: "Gym", ClimateRef: "smart1", Colour: 3},
+		{Name: "Night", ClimateRef: "smart2", Colour: 4},
+	}}
+	for _, tc := range []struct {
+		name     string
+		climates []climateDoc
+		want     []string // ref and colour of each climate
+	}{
+		{
+			name:     "same refs",
+			climates: []climateDoc{{Name: "Home", Ref: "home"}, {Name: "gym", Ref: "smart1"}},
+			want:     []string{"home 1", "smart1 3"},
+		},
+		{
+			name:     "no ref",
+			climates: []climateDoc{{Name: "Home", Ref: "home"}, {Name: "Night"}},
+			want:     []string{"home 1", "smart2 4"},
+		},
+		{
+			name:     "ref of another climate",
+			climates: []climateDoc{{Name: "Home", Ref: "home"}, {Name: "Night", Ref: "smart1"}},
+			want:     []string{"home 1", "smart2 4"},
+		},
+		{
+			name:     "name taken first",
+			climates: []climateDoc{{Name: "Party", Ref: "smart2"}, {Name: "Home", Ref: "home"}, {Name: "Night", Ref: "smart1"}},
+			want:     []string{"smart3 0", "home 1", "smart2 4"},
+		},
+		{
+			name:     "renamed",
+			climates: []climateDoc{{Name: "House", Ref: "home"}, {Name: "Workout", Ref: "smart1"}},
+			want:     []string{"home 1", "smart1 3"},
+		},
+		{
+			name:     "new",
+			climates: []climateDoc{{Name: "Home", Ref: "home"}, {Name: "Party", Ref: "smart7"}, {Name: "Guests"}},
+			want:     []string{"home 1", "smart7 0", "smart3 0"},
+		},
+	} {
+		doc := &programDoc{Climates: tc.climates}
+		for i := range doc.Climates {
+			doc.Climates[i].Heat, doc.Climates[i].Cool = 68, 75
+		}
+		// The last climate runs at night, named by its ref in doc.
+		last := tc.climates[len(tc.climates)-1]
+		ref := last.Ref
+		if ref == "" {
+			ref = last.Name
+		}
+		doc.Schedule = []scheduleDayDoc{{Days: "mon-sun", Ranges: []string{
+			"00:00-06:00 " + ref, "06:00-24:00 " + tc.climates[0].Name,
+		}}}
+
+		p, err := doc.program(old, ecobee.Fahrenheit)
+		if err != nil {
+			t.Errorf("%s: program: %v", tc.name, err)
+			continue
+		}
+		var got []string
+		for i, c := range p.Climates {
+			if c.Name != tc.climates[i].Name {
+				t.Errorf("%s: climate %d named %q, want %q", tc.name, i, c.Name, tc.climates[i].Name)
+			}
+			got = append(got, fmt.Sprintf("%s %d", c.ClimateRef, c.Colour))
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: climates = %q, want %q", tc.name, got, tc.want)
+		}
+		night := p.Climates[len(p.Climates)-1].ClimateRef
+		if p.Schedule[0][0] != night || p.Schedule[6][11] != night || p.Schedule[0][12] != p.Climates[0].ClimateRef {
+			t.Errorf("%s: schedule %v, want %s at night", tc.name, p.Schedule[0], night)
+		}
+	}
+}
+
+func TestProgramImportOtherThermostat(t *testing.T) {
+	a := testThermostat("123", "Home")
+	a.Program.Climates = append(a.Program.Climates, ecobee.Climate{Name: "Night", ClimateRef: "smart1", HeatTemp: 640, CoolTemp: 780})
+	for i := 0; i < 12; i++ {
+		a.Program.Schedule[0][i] = "smart1"
+	}
+	b := testThermostat("456", "Cottage")
+	b.Program.Climates = append(b.Program.Climates,
+		ecobee.Climate{Name: "Gym", ClimateRef: "smart1", HeatTemp: 600, CoolTemp: 700},
+		ecobee.Climate{Name: "Night", ClimateRef: "smart2", HeatTemp: 650, CoolTemp: 790, Owner: "user"})
+	c := newCLI(t, a, b)
+	y := filepath.Join(t.TempDir(), "program.yaml")
+	c.run(t, "program", "export", "--units", "F", "-o", y)
+
+	c.run(t, "program", "import", "--thermostat", "456", "--units", "F", "--yes", y)
+
+	p := c.thermostat(t, "456").Program
+	var got []string
+	for _, cl := range p.Climates {
+		got = append(got, fmt.Sprintf("%s %s %d-%d %s", cl.ClimateRef, cl.Name, cl.HeatTemp, cl.CoolTemp, cl.Owner))
+	}
+	want := []string{"home Home 680-750 ", "away Away 620-800 ", "smart2 Night 640-780 user"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("climates = %q, want %q", got, want)
+	}
+	if p.Schedule[0][0] != "smart2" || p.Schedule[0][11] != "smart2" || p.Schedule[0][12] != "home" {
+		t.Errorf("monday = %v, want smart2 until 06:00, then home", p.Schedule[0])
+	}
+}
@@ -15,7 +15,6 @@ package cmd
 
 import (
 	"log"
-	"strconv"
 
 	"github.com/golang/glog"
 	"github.com/rspier/go-ecobee/ecobee"
@@ -28,18 +27,22 @@ import (
 
 var pushGateway string
 
+// promUnit is the unit of the temperatures pushed to prometheus.  It is
+// fixed, unlike --units, so that a time series never mixes units.
+const promUnit = ecobee.Fahrenheit
+
 // promCmd represents the status command
 var promCmd = &cobra.Command{
 	Use:   "prompush",
 	Short: "Push thermostat status to prometheus.",
-	Long:  "Push thermostat status to prometheus.",
+	Long:  "Push thermostat status to prometheus.  Temperatures are in °F regardless of --units.",
 	Run: func(cmd *cobra.Command, args []string) {
 		checkRequiredFlags()
 		c := client()
 
 		tsm, err := c.GetThermostatSummary(
 			ecobee.Selection{
-				SelectionType:          "thermostats",
+				SelectionType:          ecobee.SelectionTypeThermostats,
 				SelectionMatch:         thermostat,
 				IncludeEquipmentStatus: true,
 			})
@@ -63,7 +66,7 @@ var promCmd = &cobra.Command{
 			glog.Exit("required flag --pushgateway missing")
 		}
 
-		promPush(c, &ts, t)
+		promPush(c, &ts, t, promUnit)
 	},
 }
 
@@ -72,7 +75,7 @@ func init() {
 	promCmd.Flags().StringVarP(&pushGateway, "pushgateway", "p", "", "URL of prometheus push gateway")
 }
 
-func promPush(c *ecobee.Client, ts *ecobee.ThermostatSummary, t *ecobee.Thermostat) {
+func promPush(c *ecobee.Client, ts *ecobee.ThermostatSummary, t *ecobee.Thermostat, unit ecobee.Unit) {
 
 	gauges := []struct {
 		name string
@@ -86,9 +89,9 @@ func promPush(c *ecobee.Client, ts *ecobee.ThermostatSummary, t *ecobee.Thermost
 		{"aux_heat2", boolToFloat(ts.EquipmentStatus.AuxHeat2)},
 		{"aux_heat3", boolToFloat(ts.EquipmentStatus.AuxHeat3)},
 
-		{"desired_heat", float64(t.Runtime.DesiredHeat) / 10.0},
-		{"desired_cool", float64(t.Runtime.DesiredCool) / 10.0},
-		{"temperature", float64(t.Runtime.ActualTemperature) / 10.0},
+		{"desired_heat", t.Runtime.DesiredHeat.In(unit)},
+		{"desired_cool", t.Runtime.DesiredCool.In(unit)},
+		{"temperature", t.Runtime.ActualTemperature.In(unit)},
 	}
 	for _, i := range gauges {
 		g := promauto.NewGauge(
@@ -117,10 +120,10 @@ func promPush(c *ecobee.Client, ts *ecobee.ThermostatSummary, t *ecobee.Thermost
 	for _, s := range t.RemoteSensors {
 		for _, c := range s.Capability {
 			if c.Type == "temperature" {
-				t, err := strconv.ParseFloat(c.Value, 64)
+				t, err := c.Temperature()
 				if err == nil {
 					g, _ := sensorTemp.GetMetricWithLabelValues(s.Name)
-					g.Set(t / 10.0)
+					g.Set(t.In(unit))
 				}
 			}
 			if c.Type == "occupancy" {
@@ -0,0 +1,60 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestPromPush(t *testing.T) {
+	c := newCLI(t)
+	pushed := map[string]float64{}
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dec := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+		for {
+			var mf dto.MetricFamily
+			if err := dec.Decode(&mf); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				t.Errorf("invalid push: %v", err)
+				break
+			}
+			for _, m := range mf.GetMetric() {
+				pushed[mf.GetName()] = m.GetGauge().GetValue()
+			}
+		}
+	}))
+	defer gw.Close()
+
+	// Temperatures are pushed in °F whatever --units says.
+	c.run(t, "prompush", "--units", "C", "--pushgateway", gw.URL)
+
+	for name, want := range map[string]float64{
+		"desired_heat": 68,
+		"desired_cool": 75,
+		"temperature":  71.2,
+		"fan":          0,
+	} {
+		if got, ok := pushed[name]; !ok || got != want {
+			t.Errorf("%s = %v (pushed %v), want %v", name, got, ok, want)
+		}
+	}
+}
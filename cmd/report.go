@@ -0,0 +1,329 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportStart   string
+	reportEnd     string
+	reportColumns []string
+	reportSensors bool
+	reportFormat  string
+	reportOutput  string
+	reportMeters  []string
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Download historical reports.",
+	Long:  `Download historical data recorded by ecobee.`,
+}
+
+var reportRuntimeCmd = &cobra.Command{
+	Use:   "runtime",
+	Short: "Download the runtime report.",
+	Long: `Download the runtime report, 5 minute readings of temperatures, set points
+and equipment runtime, of the thermostats given by --thermostat, a comma
+separated list.  Times are in the thermostats' time zone.  Values are as
+reported by ecobee: temperatures in Fahrenheit, runtimes in seconds.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		start, end := reportRange(cmd, c)
+		r, err := c.GetRuntimeReportContext(cmd.Context(), thermostat, start, end, reportColumns, reportSensors)
+		if err != nil {
+			glog.Exitf("GetRuntimeReport error: %v", err)
+		}
+
+		w, closeOutput := reportWriter()
+		defer closeOutput()
+		switch reportFormat {
+		case "csv":
+			err = writeRuntimeCSV(w, r, true)
+		case "jsonl":
+			err = writeRuntimeJSONL(w, r)
+		}
+		if err != nil {
+			glog.Exitf("error writing report: %v", err)
+		}
+	},
+}
+
+var reportMeterCmd = &cobra.Command{
+	Use:   "meter",
+	Short: "Download the meter report.",
+	Long: `Download the energy consumption measured by utility meters in 5 minute
+intervals, for the thermostats given by --thermostat, a comma separated list.
+Thermostats without metering report nothing.  Times are in the thermostats'
+time zone.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		start, end := reportRange(cmd, c)
+		rs, err := c.GetMeterReportContext(cmd.Context(), thermostat, start, end, reportMeters)
+		if err != nil {
+			glog.Exitf("GetMeterReport error: %v", err)
+		}
+
+		w, closeOutput := reportWriter()
+		defer closeOutput()
+		switch reportFormat {
+		case "csv":
+			err = writeMeterCSV(w, rs)
+		case "jsonl":
+			err = writeMeterJSONL(w, rs)
+		}
+		if err != nil {
+			glog.Exitf("error writing report: %v", err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportRuntimeCmd, reportMeterCmd)
+
+	reportCmd.PersistentFlags().StringVar(&reportStart, "start", "", "start of the report, YYYY-MM-DD [HH:MM] (default: a day before --end)")
+	reportCmd.PersistentFlags().StringVar(&reportEnd, "end", "", "end of the report, YYYY-MM-DD [HH:MM] (default: now)")
+	reportCmd.PersistentFlags().StringVar(&reportFormat, "format", "csv", "output format: csv or jsonl")
+	reportCmd.PersistentFlags().StringVarP(&reportOutput, "output", "o", "", "write to this file instead of stdout")
+	reportRuntimeCmd.Flags().StringSliceVar(&reportColumns, "columns", ecobee.DefaultRuntimeColumns, "report columns")
+	reportRuntimeCmd.Flags().BoolVar(&reportSensors, "sensors", false, "include remote sensor readings")
+	reportMeterCmd.Flags().StringSliceVar(&reportMeters, "meters", []string{ecobee.MeterEnergy}, "meter types")
+}
+
+// reportRange returns the time range given by --start and --end.
+func reportRange(cmd *cobra.Command, c *ecobee.Client) (time.Time, time.Time) {
+	if reportFormat != "csv" && reportFormat != "jsonl" {
+		glog.Exitf("Invalid --format %q, want csv or jsonl", reportFormat)
+	}
+	end := time.Now()
+	if reportStart == "" && reportEnd == "" {
+		return end.Add(-24 * time.Hour), end
+	}
+	loc, err := c.ThermostatTimeZone(cmd.Context(), thermostat)
+	if err != nil {
+		glog.Exitf("error retrieving time zone for %s: %v", thermostat, err)
+	}
+	if reportEnd != "" {
+		if end, err = parseLocalTime(reportEnd, loc); err != nil {
+			glog.Exitf("Invalid --end: %v", err)
+		}
+	}
+	start := end.Add(-24 * time.Hour)
+	if reportStart != "" {
+		if start, err = parseLocalTime(reportStart, loc); err != nil {
+			glog.Exitf("Invalid --start: %v", err)
+		}
+	}
+	if !end.After(start) {
+		glog.Exitf("--end must be after --start")
+	}
+	return start, end
+}
+
+// reportWriter returns the writer for --output and a function to close
+// it.
+func reportWriter() (io.Writer, func()) {
+	if reportOutput == "" || reportOutput == "-" {
+		return os.Stdout, func() {}
+	}
+	f, err := os.Create(reportOutput)
+	if err != nil {
+		glog.Exitf("error creating %s: %v", reportOutput, err)
+	}
+	return f, func() {
+		if err := f.Close(); err != nil {
+			glog.Exitf("error writing %s: %v", reportOutput, err)
+		}
+	}
+}
+
+// sensorColumn names a sensor's readings in the output, e.g. "Bedroom
+// temperature".
+func sensorColumn(s ecobee.RuntimeSensor) string {
+	return s.SensorName + " " + s.SensorType
+}
+
+// runtimeSensorColumns returns the output names of the sensor columns
+// of r, in order of appearance, and a function returning a row's sensor
+// readings by output name.
+func runtimeSensorColumns(r *ecobee.RuntimeReport) ([]string, func(ecobee.RuntimeRow) map[string]string) {
+	var cols []string
+	names := map[string]map[string]string{} // thermostat -> sensor ID -> column
+	for id, ss := range r.Sensors {
+		names[id] = map[string]string{}
+		for _, s := range ss {
+			names[id][s.SensorID] = sensorColumn(s)
+		}
+	}
+	type key struct {
+		thermostat string
+		unix       int64
+	}
+	readings := map[key]map[string]string{}
+	for _, sr := range r.SensorRows {
+		vs := map[string]string{}
+		for id, v := range sr.Values {
+			col, ok := names[sr.Thermostat][id]
+			if !ok {
+				col = id
+			}
+			vs[col] = v
+		}
+		readings[key{sr.Thermostat, sr.Time.Unix()}] = vs
+	}
+	// Order the columns as the rows' thermostats list their sensors.
+	seenThermostat, seenCol := map[string]bool{}, map[string]bool{}
+	for _, row := range r.Rows {
+		if seenThermostat[row.Thermostat] {
+			continue
+		}
+		seenThermostat[row.Thermostat] = true
+		for _, s := range r.Sensors[row.Thermostat] {
+			if col := sensorColumn(s); !seenCol[col] {
+				seenCol[col] = true
+				cols = append(cols, col)
+			}
+		}
+	}
+	return cols, func(row ecobee.RuntimeRow) map[string]string {
+		return readings[key{row.Thermostat, row.Time.Unix()}]
+	}
+}
+
+// writeRuntimeCSV writes the rows of r, preceded by a header row if
+// header is set.
+func writeRuntimeCSV(w io.Writer, r *ecobee.RuntimeReport, header bool) error {
+	sensorCols, sensorValues := runtimeSensorColumns(r)
+	cw := csv.NewWriter(w)
+	if header {
+		hdr := append([]string{"thermostat", "time"}, r.Columns...)
+		if err := cw.Write(append(hdr, sensorCols...)); err != nil {
+			return err
+		}
+	}
+	for _, row := range r.Rows {
+		rec := runtimeRecord(row, r.Columns)
+		sv := sensorValues(row)
+		for _, col := range sensorCols {
+			rec = append(rec, sv[col])
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeRuntimeJSONL writes one JSON object per row.  Numeric values are
+// written as numbers, and empty values are left out.
+func writeRuntimeJSONL(w io.Writer, r *ecobee.RuntimeReport) error {
+	_, sensorValues := runtimeSensorColumns(r)
+	enc := json.NewEncoder(w)
+	for _, row := range r.Rows {
+		if err := enc.Encode(runtimeObject(row, sensorValues(row))); err != nil {
+			return fmt.Errorf("error encoding row: %v", err)
+		}
+	}
+	return nil
+}
+
+// runtimeRecord returns the CSV record of row, with the values of
+// columns.
+func runtimeRecord(row ecobee.RuntimeRow, columns []string) []string {
+	rec := []string{row.Thermostat, row.Time.Format(time.RFC3339)}
+	for _, col := range columns {
+		rec = append(rec, row.Values[col])
+	}
+	return rec
+}
+
+// runtimeObject returns the JSON object of row, with the sensor
+// readings sensors.
+func runtimeObject(row ecobee.RuntimeRow, sensors map[string]string) map[string]interface{} {
+	obj := map[string]interface{}{
+		"thermostat": row.Thermostat,
+		"time":       row.Time.Format(time.RFC3339),
+	}
+	for col, v := range row.Values {
+		obj[col] = jsonValue(v)
+	}
+	if len(sensors) > 0 {
+		vs := map[string]interface{}{}
+		for col, v := range sensors {
+			vs[col] = jsonValue(v)
+		}
+		obj["sensors"] = vs
+	}
+	return obj
+}
+
+// jsonValue returns v as a number if it is one.
+func jsonValue(v string) interface{} {
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		return f
+	}
+	return v
+}
+
+func writeMeterCSV(w io.Writer, rs []ecobee.MeterReading) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"thermostat", "time", "meter", "value"}); err != nil {
+		return err
+	}
+	for _, r := range rs {
+		rec := []string{r.Thermostat, r.Time.Format(time.RFC3339), r.Meter, strconv.FormatFloat(r.Value, 'f', -1, 64)}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeMeterJSONL(w io.Writer, rs []ecobee.MeterReading) error {
+	enc := json.NewEncoder(w)
+	for _, r := range rs {
+		err := enc.Encode(map[string]interface{}{
+			"thermostat": r.Thermostat,
+			"time":       r.Time.Format(time.RFC3339),
+			"meter":      r.Meter,
+			"value":      r.Value,
+		})
+		if err != nil {
+			return fmt.Errorf("error encoding reading: %v", err)
+		}
+	}
+	return nil
+}
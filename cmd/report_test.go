@@ -0,0 +1,70 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+func TestReportMeter(t *testing.T) {
+	c := newCLI(t, testThermostat("123", "Home"), testThermostat("456", "Cottage"))
+	c.SetMeter("456", ecobee.MeterEnergy, 0.25)
+
+	// 07:00 to 07:15 in Toronto is three intervals; only 456 is metered.
+	got := c.run(t, "report", "meter", "--thermostat", "123,456",
+		"--start", "2017-12-01 07:00", "--end", "2017-12-01 07:15")
+	want := []string{
+		"thermostat,time,meter,value",
+		"456,2017-12-01T07:00:00-05:00,energy,0.25",
+		"456,2017-12-01T07:05:00-05:00,energy,0.25",
+		"456,2017-12-01T07:10:00-05:00,energy,0.25",
+	}
+	if got != strings.Join(want, "\n")+"\n" {
+		t.Errorf("report meter printed\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestReportMeterJSONL(t *testing.T) {
+	c := newCLI(t)
+	c.SetMeter("123", ecobee.MeterEnergy, 1.5)
+	out := filepath.Join(t.TempDir(), "meter.jsonl")
+
+	c.run(t, "report", "meter", "--start", "2017-12-01 07:00", "--end", "2017-12-01 07:10",
+		"--format", "jsonl", "-o", out)
+
+	lines := readLines(t, out)
+	if len(lines) != 2 {
+		t.Fatalf("got %d readings, want 2", len(lines))
+	}
+	var r map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &r); err != nil {
+		t.Fatalf("invalid reading %q: %v", lines[1], err)
+	}
+	want := map[string]interface{}{
+		"thermostat": "123",
+		"time":       "2017-12-01T07:05:00-05:00",
+		"meter":      "energy",
+		"value":      1.5,
+	}
+	for k, v := range want {
+		if r[k] != v {
+			t.Errorf("%s = %v, want %v", k, r[k], v)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+)
+
+// reportJobBatch is the number of thermostats per report job.
+const reportJobBatch = 25
+
+var (
+	reportJobState  string
+	reportJobCancel bool
+)
+
+var reportJobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "Download a large runtime report with report jobs.",
+	Long: `Download the runtime report of the thermostats given by --thermostat, a
+comma separated list, for the whole UTC days from --start to --end, using
+ecobee's report jobs.  Jobs take minutes to hours, but can report a year of
+many thermostats.
+
+Progress is kept in the --state file.  If the command is interrupted, run it
+again to resume where it stopped; the --start, --end, --columns, --sensors
+and --thermostat flags are then taken from the state file.  Jobs that ecobee
+cancelled or failed are created again.  --cancel cancels the jobs instead.
+
+--sensors adds the readings of remote sensors, keyed by sensor ID.  As
+thermostats have different sensors, it needs --format jsonl.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		requiredStringFlag("output", reportOutput)
+		if reportOutput == "-" {
+			glog.Exitf("report job can't write to stdout")
+		}
+		if reportJobState == "" {
+			reportJobState = reportOutput + ".state"
+		}
+		requiredStringFlag("appid", appID)
+		c := client()
+		ctx := cmd.Context()
+
+		st, err := loadJobState(reportJobState)
+		if err != nil {
+			glog.Exitf("error reading %s: %v", reportJobState, err)
+		}
+		if reportJobCancel {
+			if st == nil {
+				glog.Exitf("No report jobs in %s", reportJobState)
+			}
+			for _, j := range st.Jobs {
+				if j.ID != "" && !j.Done {
+					if err := c.CancelReportJobContext(ctx, j.ID); err != nil {
+						glog.Exitf("CancelReportJob error: %v", err)
+					}
+					fmt.Printf("Cancelled report job %s\n", j.ID)
+				}
+			}
+			if err := os.Remove(reportJobState); err != nil {
+				glog.Exitf("error removing %s: %v", reportJobState, err)
+			}
+			return
+		}
+		if st == nil {
+			st = newJobState()
+		} else {
+			fmt.Fprintf(os.Stderr, "Resuming report jobs from %s\n", reportJobState)
+		}
+
+		start, err := time.Parse(ecobee.DateLayout, st.Start)
+		if err != nil {
+			glog.Exitf("Invalid start date %q", st.Start)
+		}
+		end, err := time.Parse(ecobee.DateLayout, st.End)
+		if err != nil {
+			glog.Exitf("Invalid end date %q", st.End)
+		}
+
+		// Create all jobs first, so ecobee works on them together.
+		for i := range st.Jobs {
+			j := &st.Jobs[i]
+			if j.ID != "" {
+				continue
+			}
+			if j.Failed != "" {
+				fmt.Fprintf(os.Stderr, "Retrying the report job for %s, which failed: %s\n", j.Thermostats, j.Failed)
+				j.Failed = ""
+			}
+			sel := ecobee.Selection{
+				SelectionType:  ecobee.SelectionTypeThermostats,
+				SelectionMatch: j.Thermostats,
+			}
+			if j.ID, err = c.CreateRuntimeReportJobContext(ctx, sel, start, end, st.Columns, st.IncludeSensors); err != nil {
+				glog.Exitf("CreateRuntimeReportJob error: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "Created report job %s for %s\n", j.ID, j.Thermostats)
+			st.save()
+		}
+
+		f, err := os.OpenFile(reportOutput, os.O_WRONLY|os.O_CREATE, 0666)
+		if err != nil {
+			glog.Exitf("error opening %s: %v", reportOutput, err)
+		}
+		// Drop anything written after the last recorded file.
+		if err := f.Truncate(st.OutputSize); err != nil {
+			glog.Exitf("error truncating %s: %v", reportOutput, err)
+		}
+		if _, err := f.Seek(st.OutputSize, io.SeekStart); err != nil {
+			glog.Exitf("error seeking %s: %v", reportOutput, err)
+		}
+
+		for i := range st.Jobs {
+			j := &st.Jobs[i]
+			if j.Done {
+				continue
+			}
+			job, err := c.WaitReportJob(ctx, j.ID, func(job *ecobee.ReportJob) {
+				fmt.Fprintf(os.Stderr, "Report job %s: %s\n", job.JobID, job.Status)
+			})
+			if err != nil {
+				if job != nil {
+					st.failed(j, job)
+					glog.Exitf("WaitReportJob error: %v; run the command again to retry the job, or with --cancel to give up", err)
+				}
+				glog.Exitf("WaitReportJob error: %v", err)
+			}
+			// The files of a job share the time zones of its thermostats.
+			zones, err := c.ThermostatTimeZones(ctx, j.Thermostats)
+			if err != nil {
+				glog.Exitf("error retrieving time zones for %s: %v", j.Thermostats, err)
+			}
+			for _, file := range job.Files {
+				if slices.Contains(j.Files, file) {
+					continue
+				}
+				write, flush := st.rowWriter(f, st.OutputSize == 0)
+				if err := c.DownloadReportJobFile(ctx, file, st.Columns, zones, write); err != nil {
+					glog.Exitf("DownloadReportJobFile error: %v", err)
+				}
+				if err := flush(); err != nil {
+					glog.Exitf("error writing report: %v", err)
+				}
+				if err := f.Sync(); err != nil {
+					glog.Exitf("error writing %s: %v", reportOutput, err)
+				}
+				if st.OutputSize, err = f.Seek(0, io.SeekCurrent); err != nil {
+					glog.Exitf("error seeking %s: %v", reportOutput, err)
+				}
+				j.Files = append(j.Files, file)
+				st.save()
+			}
+			j.Done = true
+			st.save()
+		}
+
+		if err := f.Close(); err != nil {
+			glog.Exitf("error writing %s: %v", reportOutput, err)
+		}
+		if err := os.Remove(reportJobState); err != nil {
+			glog.Exitf("error removing %s: %v", reportJobState, err)
+		}
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(reportJobCmd)
+	reportJobCmd.Flags().StringSliceVar(&reportColumns, "columns", ecobee.DefaultRuntimeColumns, "report columns")
+	reportJobCmd.Flags().StringVar(&reportJobState, "state", "", "file keeping the progress of the jobs (default: --output with .state appended)")
+	reportJobCmd.Flags().BoolVar(&reportJobCancel, "cancel", false, "cancel the jobs in the --state file")
+	reportJobCmd.Flags().BoolVar(&reportSensors, "sensors", false, "include remote sensor readings")
+}
+
+// jobState is the progress of a report job command, saved so it can be
+// resumed.
+type jobState struct {
+	Start   string
+	End     string
+	Columns []string
+	Format  string
+	// IncludeSensors adds the readings of remote sensors.
+	IncludeSensors bool `json:",omitempty"`
+	// OutputSize is the size of the output once the files recorded
+	// in Jobs were written.
+	OutputSize int64
+	Jobs       []jobProgress
+}
+
+type jobProgress struct {
+	Thermostats string
+	ID          string   `json:",omitempty"`
+	Files       []string `json:",omitempty"` // written to the output
+	Done        bool
+	// Failed is why the last job for Thermostats was cancelled or
+	// failed.  It is recreated when the command is run again.
+	Failed string `json:",omitempty"`
+}
+
+// newJobState returns the state of a new report job command, from its
+// flags.  --start is required, and --end defaults to today.
+func newJobState() *jobState {
+	checkRequiredFlags()
+	requiredStringFlag("start", reportStart)
+	if reportFormat != "csv" && reportFormat != "jsonl" {
+		glog.Exitf("Invalid --format %q, want csv or jsonl", reportFormat)
+	}
+	end := reportEnd
+	if end == "" {
+		end = time.Now().UTC().Format(ecobee.DateLayout)
+	}
+	for _, d := range []struct{ name, value string }{{"start", reportStart}, {"end", end}} {
+		if _, err := time.Parse(ecobee.DateLayout, d.value); err != nil {
+			glog.Exitf("Invalid --%s %q, want YYYY-MM-DD", d.name, d.value)
+		}
+	}
+	if end < reportStart {
+		glog.Exitf("--end must not be before --start")
+	}
+	if reportSensors && reportFormat != "jsonl" {
+		glog.Exitf("--sensors needs --format jsonl")
+	}
+
+	st := &jobState{Start: reportStart, End: end, Columns: reportColumns, Format: reportFormat, IncludeSensors: reportSensors}
+	ids := strings.Split(thermostat, ",")
+	for len(ids) > 0 {
+		n := min(len(ids), reportJobBatch)
+		st.Jobs = append(st.Jobs, jobProgress{Thermostats: strings.Join(ids[:n], ",")})
+		ids = ids[n:]
+	}
+	return st
+}
+
+// failed records that job, the job of j, was cancelled or failed, so
+// that it is created again when the command is resumed.  Such jobs
+// publish no files, so none of it was written.
+func (st *jobState) failed(j *jobProgress, job *ecobee.ReportJob) {
+	j.Failed = fmt.Sprintf("job %s %s: %s", job.JobID, job.Status, job.Message)
+	j.ID = ""
+	j.Files = nil
+	st.save()
+}
+
+// rowWriter returns a function writing a downloaded row to w in the
+// output format, and a function flushing what it wrote.  The CSV header
+// row is written ahead of the first row if header is set.  The values of
+// columns that weren't requested are sensor readings.
+func (st *jobState) rowWriter(w io.Writer, header bool) (func(ecobee.RuntimeRow) error, func() error) {
+	bw := bufio.NewWriter(w)
+	if st.Format == "csv" {
+		cw := csv.NewWriter(bw)
+		write := func(row ecobee.RuntimeRow) error {
+			if header {
+				header = false
+				if err := cw.Write(append([]string{"thermostat", "time"}, st.Columns...)); err != nil {
+					return err
+				}
+			}
+			return cw.Write(runtimeRecord(row, st.Columns))
+		}
+		return write, func() error {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+			return bw.Flush()
+		}
+	}
+	enc := json.NewEncoder(bw)
+	write := func(row ecobee.RuntimeRow) error {
+		var sensors map[string]string
+		if st.IncludeSensors {
+			for col, v := range row.Values {
+				if !slices.Contains(st.Columns, col) {
+					if sensors == nil {
+						sensors = map[string]string{}
+					}
+					sensors[col] = v
+					delete(row.Values, col)
+				}
+			}
+		}
+		if err := enc.Encode(runtimeObject(row, sensors)); err != nil {
+			return fmt.Errorf("error encoding row: %v", err)
+		}
+		return nil
+	}
+	return write, bw.Flush
+}
+
+// loadJobState reads the state file at name.  It returns nil if there is
+// none.
+func loadJobState(name string) (*jobState, error) {
+	b, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var st jobState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// save writes the state to the --state file, replacing it atomically.
+func (st *jobState) save() {
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		glog.Exitf("error marshaling state: %v", err)
+	}
+	tmp := reportJobState + ".tmp"
+	if err := os.WriteFile(tmp, b, 0666); err != nil {
+		glog.Exitf("error writing %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, reportJobState); err != nil {
+		glog.Exitf("error writing %s: %v", reportJobState, err)
+	}
+}
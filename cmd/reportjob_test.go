@@ -0,0 +1,128 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+// readLines returns the lines of file name.
+func readLines(t *testing.T, name string) []string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
+
+func TestReportJob(t *testing.T) {
+	c := newCLI(t, testThermostat("123", "Home"), testThermostat("456", "Cottage"))
+	out := filepath.Join(t.TempDir(), "report.csv")
+
+	c.run(t, "report", "job", "--thermostat", "123,456", "--start", "2017-12-01", "--end", "2017-12-01",
+		"--columns", "zoneAveTemp", "-o", out)
+
+	lines := readLines(t, out)
+	if want := "thermostat,time,zoneAveTemp"; lines[0] != want {
+		t.Errorf("header = %q, want %q", lines[0], want)
+	}
+	// A UTC day of 5 minute intervals for each thermostat.
+	if n := len(lines) - 1; n != 2*288 {
+		t.Errorf("got %d rows, want %d", n, 2*288)
+	}
+	if want := "123,2017-11-30T19:00:00-05:00,71.2"; lines[1] != want {
+		t.Errorf("first row = %q, want %q", lines[1], want)
+	}
+	if _, err := os.Stat(out + ".state"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("state file left behind: %v", err)
+	}
+}
+
+func TestReportJobSensors(t *testing.T) {
+	th := testThermostat("123", "Home")
+	th.RemoteSensors = []ecobee.RemoteSensor{{
+		ID:   "rs:100",
+		Name: "Bedroom",
+		Capability: []ecobee.RemoteSensorCapability{
+			{ID: "1", Type: "temperature", Value: "665"},
+			{ID: "2", Type: "occupancy", Value: "true"},
+		},
+	}}
+	c := newCLI(t, th)
+	out := filepath.Join(t.TempDir(), "report.jsonl")
+
+	c.run(t, "report", "job", "--start", "2017-12-01", "--end", "2017-12-01",
+		"--columns", "zoneAveTemp", "--sensors", "--format", "jsonl", "-o", out)
+
+	lines := readLines(t, out)
+	if len(lines) != 288 {
+		t.Errorf("got %d rows, want 288", len(lines))
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil {
+		t.Fatalf("invalid row %q: %v", lines[0], err)
+	}
+	if row["zoneAveTemp"] != 71.2 {
+		t.Errorf("zoneAveTemp = %v, want 71.2", row["zoneAveTemp"])
+	}
+	sensors, _ := row["sensors"].(map[string]interface{})
+	if sensors["rs:100:1"] != 66.5 || sensors["rs:100:2"] != "true" {
+		t.Errorf("sensors = %v, want rs:100:1 66.5 and rs:100:2 true", row["sensors"])
+	}
+	if _, ok := row["rs:100:1"]; ok {
+		t.Errorf("row = %v, want sensor readings only under sensors", row)
+	}
+}
+
+func TestReportJobRetryFailed(t *testing.T) {
+	c := newCLI(t)
+	out := filepath.Join(t.TempDir(), "report.csv")
+	reportJobState = out + ".state"
+	st := &jobState{
+		Start:   "2017-12-01",
+		End:     "2017-12-01",
+		Columns: []string{"zoneAveTemp"},
+		Format:  "csv",
+		Jobs:    []jobProgress{{Thermostats: "123", ID: "job9"}},
+	}
+
+	st.failed(&st.Jobs[0], &ecobee.ReportJob{JobID: "job9", Status: ecobee.JobError, Message: "out of space"})
+
+	saved, err := loadJobState(reportJobState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j := saved.Jobs[0]; j.ID != "" || j.Done || !strings.Contains(j.Failed, "job9 error: out of space") {
+		t.Errorf("saved job = %+v, want a failed job without ID", j)
+	}
+
+	// Resuming creates the job again.
+	c.run(t, "report", "job", "-o", out)
+
+	if n := len(readLines(t, out)) - 1; n != 288 {
+		t.Errorf("got %d rows, want 288", n)
+	}
+	if _, err := os.Stat(reportJobState); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("state file left behind: %v", err)
+	}
+}
@@ -68,6 +68,8 @@ func init() {
 	RootCmd.PersistentFlags().StringP("thermostat", "t", "", "thermostat id")
 	RootCmd.PersistentFlags().StringP("appid", "i", "", "app id")
 	RootCmd.PersistentFlags().StringP("authcache", "", "", "auth cache file")
+	RootCmd.PersistentFlags().StringP("units", "u", "", "temperature units: F or C (default: the thermostat's setting)")
+	RootCmd.PersistentFlags().StringP("apiurl", "", "", "ecobee API base URL (default "+ecobee.DefaultBaseURL+")")
 
 	// This is a little messy... is there a nicer way to do this?
 	ck := func(err error) {
@@ -78,6 +80,8 @@ func init() {
 	ck(viper.BindPFlag("thermostat", RootCmd.PersistentFlags().Lookup("thermostat")))
 	ck(viper.BindPFlag("appid", RootCmd.PersistentFlags().Lookup("appid")))
 	ck(viper.BindPFlag("authcache", RootCmd.PersistentFlags().Lookup("authcache")))
+	ck(viper.BindPFlag("units", RootCmd.PersistentFlags().Lookup("units")))
+	ck(viper.BindPFlag("apiurl", RootCmd.PersistentFlags().Lookup("apiurl")))
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -123,5 +127,12 @@ func client() *ecobee.Client {
 	}
 	glog.V(1).Infof("authCache: %s", ac)
 	// replace authCacheFile with authCache flag
-	return ecobee.NewClient(appID, ac)
+	opts := []ecobee.ClientOption{
+		ecobee.WithUserAgent("go-ecobee"),
+		ecobee.WithRetryPolicy(ecobee.DefaultRetryPolicy),
+	}
+	if u := viper.GetString("apiurl"); u != "" {
+		opts = append(opts, ecobee.WithBaseURL(u))
+	}
+	return ecobee.NewClientWithOptions(appID, ac, opts...)
 }
@@ -0,0 +1,163 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/golang/glog"
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleList   bool
+	scheduleDryRun bool
+)
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Show the weekly schedule.",
+	Long: `Show the weekly program schedule as a grid of half hour slots, one
+letter per comfort setting, or with --list as time ranges.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		p, err := c.GetProgram(thermostat)
+		if err != nil {
+			glog.Exitf("error retrieving program for %s: %v", thermostat, err)
+		}
+		if scheduleList {
+			printScheduleList(p)
+		} else {
+			printScheduleGrid(p)
+		}
+	},
+}
+
+var scheduleSetCmd = &cobra.Command{
+	Use:   "set CHANGE...",
+	Short: "Change the weekly schedule.",
+	Long: `Change parts of the weekly schedule.  Each CHANGE is days, a time range
+and a comfort setting, e.g.
+
+  go-ecobee schedule set "weekdays 06:00-08:30 home" "sat,sun 23:00-24:00 sleep"
+
+Days are mon..sun, ranges like mon-thu, weekdays, weekends or daily.
+Times must be on the hour or half hour.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		p, err := c.GetProgram(thermostat)
+		if err != nil {
+			glog.Exitf("error retrieving program for %s: %v", thermostat, err)
+		}
+		for _, a := range args {
+			ch, err := p.ParseScheduleChange(a)
+			if err != nil {
+				glog.Exitf("%v", err)
+			}
+			if err := p.SetSchedule(ch); err != nil {
+				glog.Exitf("%v", err)
+			}
+		}
+		printScheduleGrid(p)
+		if scheduleDryRun {
+			return
+		}
+		if err := c.UpdateProgram(thermostat, *p); err != nil {
+			glog.Exitf("UpdateProgram error: %v", err)
+		}
+		fmt.Printf("Successfully updated schedule\n")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleSetCmd)
+
+	scheduleCmd.Flags().BoolVar(&scheduleList, "list", false, "list time ranges instead of a grid")
+	scheduleSetCmd.Flags().BoolVar(&scheduleDryRun, "dry-run", false, "show the new schedule without saving it")
+}
+
+// climateLetters assigns each climate a distinct letter for the grid,
+// preferring the first letter of its name.
+func climateLetters(p *ecobee.Program) map[string]rune {
+	letters := map[string]rune{}
+	used := map[rune]bool{}
+	for _, c := range p.Climates {
+		cands := []rune(strings.ToUpper(c.Name + c.ClimateRef))
+		cands = append(cands, []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")...)
+		for _, r := range cands {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				if !used[r] {
+					used[r] = true
+					letters[c.ClimateRef] = r
+					break
+				}
+			}
+		}
+	}
+	return letters
+}
+
+func printScheduleGrid(p *ecobee.Program) {
+	letters := climateLetters(p)
+
+	var hdr strings.Builder
+	for h := 0; h < 24; h += 2 {
+		fmt.Fprintf(&hdr, "%02d  ", h)
+	}
+	fmt.Printf("     %s\n", strings.TrimSpace(hdr.String()))
+	for i, d := range ecobee.Weekdays {
+		var b strings.Builder
+		if i < len(p.Schedule) {
+			for _, ref := range p.Schedule[i] {
+				r, ok := letters[ref]
+				if !ok {
+					r = '?'
+				}
+				b.WriteRune(r)
+			}
+		}
+		fmt.Printf("%s  %s\n", d.String()[:3], b.String())
+	}
+
+	var legend []string
+	for _, c := range p.Climates {
+		legend = append(legend, fmt.Sprintf("%c=%s", letters[c.ClimateRef], c.Name))
+	}
+	fmt.Printf("\n%s\n", strings.Join(legend, " "))
+}
+
+func printScheduleList(p *ecobee.Program) {
+	for _, d := range ecobee.Weekdays {
+		var rs []string
+		for _, r := range p.DayRanges(d) {
+			name := r.ClimateRef
+			if c := p.Climate(r.ClimateRef); c != nil {
+				name = c.Name
+			}
+			rs = append(rs, fmt.Sprintf("%s-%s %s", ecobee.FormatClock(r.Start), ecobee.FormatClock(r.End), name))
+		}
+		fmt.Printf("%s  %s\n", d.String()[:3], strings.Join(rs, ", "))
+	}
+}
@@ -15,7 +15,6 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/golang/glog"
@@ -37,7 +36,7 @@ var statusCmd = &cobra.Command{
 
 		tsm, err := c.GetThermostatSummary(
 			ecobee.Selection{
-				SelectionType:          "thermostats",
+				SelectionType:          ecobee.SelectionTypeThermostats,
 				SelectionMatch:         thermostat,
 				IncludeEquipmentStatus: true,
 			})
@@ -52,16 +51,17 @@ var statusCmd = &cobra.Command{
 			glog.Exitf("thermostat %s missing from ThermostatSummary", thermostat)
 		}
 
-		t, err := c.GetThermostat(thermostat)
+		t, err := c.GetThermostat(thermostat, unitSelection()...)
 		if err != nil {
 			glog.Exitf("error retrieving thermostat %s: %v", thermostat, err)
 		}
+		unit := displayUnit(t)
 
 		switch format {
 		case "machine":
-			machineStatus(c, &ts, t)
+			machineStatus(c, &ts, t, unit)
 		default:
-			showStatus(c, &ts, t)
+			showStatus(c, &ts, t, unit)
 		}
 	},
 }
@@ -71,13 +71,13 @@ func init() {
 	statusCmd.Flags().StringVarP(&format, "format", "f", "", "output format")
 }
 
-func showStatus(c *ecobee.Client, ts *ecobee.ThermostatSummary, t *ecobee.Thermostat) {
+func showStatus(c *ecobee.Client, ts *ecobee.ThermostatSummary, t *ecobee.Thermostat, unit ecobee.Unit) {
 	running := formatEquipmentStatus(ts)
 
-	fmt.Printf("Current Settings (%s): %.1f - %.1f.  Fan: %s%s\n",
+	fmt.Printf("Current Settings (%s): %s - %s.  Fan: %s%s\n",
 		strings.ToTitle(t.Program.CurrentClimateRef),
-		float64(t.Runtime.DesiredHeat)/10.0,
-		float64(t.Runtime.DesiredCool)/10.0,
+		t.Runtime.DesiredHeat.Format(unit),
+		t.Runtime.DesiredCool.Format(unit),
 		t.Runtime.DesiredFanMode,
 		running)
 
@@ -86,9 +86,9 @@ func showStatus(c *ecobee.Client, ts *ecobee.ThermostatSummary, t *ecobee.Thermo
 		if ev.Running {
 			switch ev.Type {
 			case "hold":
-				fmt.Printf("Holding at %.1f - %.1f (Fan: %s) until %s %s\n",
-					float64(ev.HeatHoldTemp)/10.0,
-					float64(ev.CoolHoldTemp)/10.0,
+				fmt.Printf("Holding at %s - %s (Fan: %s) until %s %s\n",
+					ev.HeatHoldTemp.Format(unit),
+					ev.CoolHoldTemp.Format(unit),
 					ev.Fan,
 					ev.EndDate,
 					ev.EndTime)
@@ -99,15 +99,15 @@ func showStatus(c *ecobee.Client, ts *ecobee.ThermostatSummary, t *ecobee.Thermo
 		}
 	}
 
-	fmt.Printf("Temperature: %.1f\n", float64(t.Runtime.ActualTemperature)/10.0)
+	fmt.Printf("Temperature: %s\n", t.Runtime.ActualTemperature.Format(unit))
 
 	for _, s := range t.RemoteSensors {
 		var temp, occ string
 		for _, c := range s.Capability {
 			if c.Type == "temperature" {
-				t, err := strconv.ParseFloat(c.Value, 64)
+				t, err := c.Temperature()
 				if err == nil {
-					temp = fmt.Sprintf("%.1f", t/10.0)
+					temp = t.Format(unit)
 				}
 			}
 			if c.Type == "occupancy" {
@@ -152,18 +152,18 @@ func writeMetric(name string, val float64) {
 	fmt.Printf("%s %f\n", name, val)
 }
 
-func machineStatus(c *ecobee.Client, ts *ecobee.ThermostatSummary, t *ecobee.Thermostat) {
+func machineStatus(c *ecobee.Client, ts *ecobee.ThermostatSummary, t *ecobee.Thermostat, unit ecobee.Unit) {
 
-	writeMetric("desired_heat", float64(t.Runtime.DesiredHeat)/10.0)
-	writeMetric("desired_cool", float64(t.Runtime.DesiredCool)/10.0)
-	writeMetric("temperature", float64(t.Runtime.ActualTemperature)/10.0)
+	writeMetric("desired_heat", t.Runtime.DesiredHeat.In(unit))
+	writeMetric("desired_cool", t.Runtime.DesiredCool.In(unit))
+	writeMetric("temperature", t.Runtime.ActualTemperature.In(unit))
 
 	for _, s := range t.RemoteSensors {
 		for _, c := range s.Capability {
 			if c.Type == "temperature" {
-				t, err := strconv.ParseFloat(c.Value, 64)
+				t, err := c.Temperature()
 				if err == nil {
-					writeMetric(fmt.Sprintf("sensor_temperature{name=%q}", s.Name), t/10.0)
+					writeMetric(fmt.Sprintf("sensor_temperature{name=%q}", s.Name), t.In(unit))
 				}
 			}
 			if c.Type == "occupancy" {
@@ -0,0 +1,52 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	c := newCLI(t)
+	c.SetEquipmentStatus("123", "fan")
+
+	out := c.run(t, "status")
+
+	for _, want := range []string{
+		"Current Settings (HOME): 68.0 - 75.0.  Fan: auto (running)",
+		"Temperature: 71.2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	}
+}
+
+func TestStatusHold(t *testing.T) {
+	c := newCLI(t)
+	c.run(t, "hold", "--heat", "70", "--cool", "76")
+
+	out := c.run(t, "status", "--format", "machine")
+
+	for _, want := range []string{
+		"desired_heat 70.000000",
+		"desired_cool 76.000000",
+		"fan 0.000000",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	}
+}
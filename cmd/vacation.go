@@ -0,0 +1,153 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vacationStart, vacationEnd string
+	vacationHeat, vacationCool string
+	vacationFan                string
+	vacationFanMinOnTime       int
+)
+
+// vacationCmd represents the vacation command
+var vacationCmd = &cobra.Command{
+	Use:   "vacation",
+	Short: "Manage vacations.",
+	Long:  `List, add and remove vacation events.`,
+}
+
+var vacationListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List vacations.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		t, err := c.GetThermostat(thermostat, unitSelection()...)
+		if err != nil {
+			glog.Exitf("error retrieving thermostat %s: %v", thermostat, err)
+		}
+		unit := displayUnit(t)
+		n := 0
+		for _, ev := range t.Events {
+			if ev.Type != "vacation" {
+				continue
+			}
+			n++
+			running := ""
+			if ev.Running {
+				running = " (running)"
+			}
+			fmt.Printf("%s: %s %s - %s %s  %s - %s  Fan: %s%s\n",
+				ev.Name,
+				ev.StartDate, ev.StartTime,
+				ev.EndDate, ev.EndTime,
+				ev.HeatHoldTemp.Format(unit),
+				ev.CoolHoldTemp.Format(unit),
+				ev.Fan,
+				running)
+		}
+		if n == 0 {
+			fmt.Printf("No vacations\n")
+		}
+	},
+}
+
+var vacationAddCmd = &cobra.Command{
+	Use:   "add NAME",
+	Short: "Add a vacation.",
+	Long: `Add a vacation holding the temperature between --heat and --cool
+from --start (default now) to --end.  Times are in the thermostat's time
+zone, e.g. "2017-12-24 15:00".`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		requiredStringFlag("end", vacationEnd)
+		requiredStringFlag("heat", vacationHeat)
+		requiredStringFlag("cool", vacationCool)
+		c := client()
+
+		unit, ok := configuredUnit()
+		if !ok {
+			t, err := c.GetThermostat(thermostat, unitSelection()...)
+			if err != nil {
+				glog.Exitf("error retrieving thermostat %s: %v", thermostat, err)
+			}
+			unit = displayUnit(t)
+		}
+		loc, err := c.ThermostatTimeZone(cmd.Context(), thermostat)
+		if err != nil {
+			glog.Exitf("error determining time zone: %v", err)
+		}
+
+		v := ecobee.Vacation{
+			Name:         args[0],
+			HeatHoldTemp: parseTempFlag("heat", vacationHeat, unit),
+			CoolHoldTemp: parseTempFlag("cool", vacationCool, unit),
+			Fan:          vacationFan,
+			FanMinOnTime: vacationFanMinOnTime,
+		}
+		if vacationStart != "" {
+			if v.Start, err = parseLocalTime(vacationStart, loc); err != nil {
+				glog.Exitf("Invalid --start: %v", err)
+			}
+		}
+		if v.End, err = parseLocalTime(vacationEnd, loc); err != nil {
+			glog.Exitf("Invalid --end: %v", err)
+		}
+
+		if err := c.CreateVacation(thermostat, v); err != nil {
+			glog.Exitf("CreateVacation error: %v", err)
+		}
+		fmt.Printf("Successfully created vacation %q\n", v.Name)
+	},
+}
+
+var vacationRmCmd = &cobra.Command{
+	Use:   "rm NAME...",
+	Short: "Remove vacations.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		checkRequiredFlags()
+		c := client()
+
+		for _, name := range args {
+			if err := c.DeleteVacation(thermostat, name); err != nil {
+				glog.Exitf("DeleteVacation error: %v", err)
+			}
+			fmt.Printf("Successfully deleted vacation %q\n", name)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(vacationCmd)
+	vacationCmd.AddCommand(vacationListCmd, vacationAddCmd, vacationRmCmd)
+
+	vacationAddCmd.Flags().StringVar(&vacationStart, "start", "", "start time (default now)")
+	vacationAddCmd.Flags().StringVar(&vacationEnd, "end", "", "end time")
+	vacationAddCmd.Flags().StringVar(&vacationHeat, "heat", "", "heat temp")
+	vacationAddCmd.Flags().StringVar(&vacationCool, "cool", "", "cool temp")
+	vacationAddCmd.Flags().StringVar(&vacationFan, "fan", "auto", "fan mode: auto or on")
+	vacationAddCmd.Flags().IntVar(&vacationFanMinOnTime, "fan-min-on-time", 0, "minimum fan minutes per hour")
+}
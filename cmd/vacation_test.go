@@ -0,0 +1,41 @@
+// Copyright © 2017 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import "testing"
+
+func TestVacation(t *testing.T) {
+	c := newCLI(t)
+
+	// Times are in the thermostat's time zone.
+	out := c.run(t, "vacation", "add", "Holidays", "--start", "2099-12-24 15:00", "--end", "2100-01-02 12:30",
+		"--heat", "62", "--cool", "80", "--fan", "on")
+	if want := "Successfully created vacation \"Holidays\"\n"; out != want {
+		t.Errorf("vacation add printed %q, want %q", out, want)
+	}
+	ev := c.thermostat(t, "123").Events[0]
+	if ev.StartDate != "2099-12-24" || ev.StartTime != "15:00:00" || ev.EndDate != "2100-01-02" || ev.EndTime != "12:30:00" {
+		t.Errorf("vacation from %s %s to %s %s, want 2099-12-24 15:00:00 to 2100-01-02 12:30:00", ev.StartDate, ev.StartTime, ev.EndDate, ev.EndTime)
+	}
+
+	want := "Holidays: 2099-12-24 15:00:00 - 2100-01-02 12:30:00  62.0 - 80.0  Fan: on\n"
+	if got := c.run(t, "vacation", "list"); got != want {
+		t.Errorf("vacation list printed %q, want %q", got, want)
+	}
+
+	c.run(t, "vacation", "rm", "Holidays")
+	if got, want := c.run(t, "vacation", "list"), "No vacations\n"; got != want {
+		t.Errorf("vacation list printed %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AckType is the response to an alert passed to AcknowledgeAlert.
+type AckType string
+
+const (
+	AckAccept  AckType = "accept"
+	AckDecline AckType = "decline"
+	// AckSnooze defers the alert; the thermostat raises it again
+	// later.
+	AckSnooze AckType = "defer"
+	// AckUnacknowledged is reported for alerts that have not been
+	// acknowledged yet.
+	AckUnacknowledged AckType = "unacknowledged"
+)
+
+// AcknowledgeParams are the parameters of the acknowledge function.
+type AcknowledgeParams struct {
+	ThermostatIdentifier string  `json:"thermostatIdentifier"`
+	AckRef               string  `json:"ackRef"`
+	AckType              AckType `json:"ackType"`
+	RemindMeLater        bool    `json:"remindMeLater,omitempty"`
+}
+
+// AlertCategory groups alerts by what they are about.
+type AlertCategory string
+
+const (
+	AlertReminder    AlertCategory = "reminder"
+	AlertTemperature AlertCategory = "temperature"
+	AlertHumidity    AlertCategory = "humidity"
+	AlertEquipment   AlertCategory = "equipment"
+	AlertMessage     AlertCategory = "message"
+)
+
+// AlertCategories lists all alert categories.
+var AlertCategories = []AlertCategory{AlertReminder, AlertTemperature, AlertHumidity, AlertEquipment, AlertMessage}
+
+// notificationCategories maps Alert.NotificationType to a category.
+// Unknown types are equipment alerts.
+var notificationCategories = map[string]AlertCategory{
+	"furnaceFilter":      AlertReminder,
+	"humidifierFilter":   AlertReminder,
+	"dehumidifierFilter": AlertReminder,
+	"ventilator":         AlertReminder,
+	"ac":                 AlertReminder,
+	"airFilter":          AlertReminder,
+	"airCleaner":         AlertReminder,
+	"uvLamp":             AlertReminder,
+	"temp":               AlertTemperature,
+	"lowTemp":            AlertTemperature,
+	"highTemp":           AlertTemperature,
+	"lowHumidity":        AlertHumidity,
+	"highHumidity":       AlertHumidity,
+}
+
+// ParseAlertCategory parses a category name, ignoring case.
+func ParseAlertCategory(s string) (AlertCategory, error) {
+	for _, c := range AlertCategories {
+		if strings.EqualFold(s, string(c)) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown alert category %q (known: %v)", s, AlertCategories)
+}
+
+// Category returns the category of the alert: maintenance reminders
+// such as filter changes, low and high temperature or humidity alerts,
+// equipment faults, and messages.
+func (a *Alert) Category() AlertCategory {
+	if a.AlertType == "message" {
+		return AlertMessage
+	}
+	if c, ok := notificationCategories[a.NotificationType]; ok {
+		return c
+	}
+	return AlertEquipment
+}
+
+// Open reports whether the alert still needs a response, i.e. it has
+// not been accepted or declined.
+func (a *Alert) Open() bool {
+	switch AckType(a.Acknowledgement) {
+	case AckAccept, AckDecline:
+		return false
+	}
+	return true
+}
+
+// When returns the time the alert was raised.  loc is the thermostat's
+// time zone, see Thermostat.TimeZone.
+func (a *Alert) When(loc *time.Location) (time.Time, error) {
+	return ParseDateTime(a.Date, a.Time, loc)
+}
+
+// GetAlerts fetches the alerts of a single thermostat.
+func (c *Client) GetAlerts(thermostat string) ([]Alert, error) {
+	return c.GetAlertsContext(context.Background(), thermostat)
+}
+
+// GetAlertsContext is like GetAlerts, using ctx for the request.
+func (c *Client) GetAlertsContext(ctx context.Context, thermostat string) ([]Alert, error) {
+	ts, err := c.GetThermostatsContext(ctx, Selection{
+		SelectionType:  SelectionTypeThermostats,
+		SelectionMatch: thermostat,
+		IncludeAlerts:  true,
+	})
+	if err != nil {
+		return nil, err
+	} else if len(ts) != 1 {
+		return nil, fmt.Errorf("got %d thermostats, wanted 1", len(ts))
+	}
+	return ts[0].Alerts, nil
+}
+
+// AcknowledgeAlert responds to the alert with acknowledge reference ref
+// (Alert.AcknowledgeRef) on thermostat.  Snoozed alerts are raised
+// again later.
+func (c *Client) AcknowledgeAlert(thermostat, ref string, ack AckType) error {
+	return c.AcknowledgeAlertContext(context.Background(), thermostat, ref, ack)
+}
+
+// AcknowledgeAlertContext is like AcknowledgeAlert, using ctx for the
+// request.
+func (c *Client) AcknowledgeAlertContext(ctx context.Context, thermostat, ref string, ack AckType) error {
+	switch ack {
+	case AckAccept, AckDecline, AckSnooze:
+	default:
+		return fmt.Errorf("invalid acknowledgement %q", ack)
+	}
+	if ref == "" {
+		return fmt.Errorf("alert acknowledge ref must not be empty")
+	}
+	r := &UpdateThermostatRequest{
+		Selection: Selection{
+			SelectionType:  SelectionTypeThermostats,
+			SelectionMatch: thermostat,
+		},
+		Functions: []Function{
+			{
+				Type: "acknowledge",
+				Params: AcknowledgeParams{
+					ThermostatIdentifier: thermostat,
+					AckRef:               ref,
+					AckType:              ack,
+					RemindMeLater:        ack == AckSnooze,
+				},
+			},
+		},
+	}
+	return c.UpdateThermostatContext(ctx, *r)
+}
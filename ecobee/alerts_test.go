@@ -0,0 +1,120 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+func TestAlertCategory(t *testing.T) {
+	for _, tc := range []struct {
+		alert ecobee.Alert
+		want  ecobee.AlertCategory
+	}{
+		{ecobee.Alert{AlertType: "alert", NotificationType: "furnaceFilter"}, ecobee.AlertReminder},
+		{ecobee.Alert{AlertType: "alert", NotificationType: "uvLamp"}, ecobee.AlertReminder},
+		{ecobee.Alert{AlertType: "alert", NotificationType: "lowTemp"}, ecobee.AlertTemperature},
+		{ecobee.Alert{AlertType: "alert", NotificationType: "highTemp"}, ecobee.AlertTemperature},
+		{ecobee.Alert{AlertType: "alert", NotificationType: "highHumidity"}, ecobee.AlertHumidity},
+		{ecobee.Alert{AlertType: "alert", NotificationType: "hvac"}, ecobee.AlertEquipment},
+		{ecobee.Alert{AlertType: "alert", NotificationType: "auxOutdoor"}, ecobee.AlertEquipment},
+		{ecobee.Alert{AlertType: "alert"}, ecobee.AlertEquipment},
+		{ecobee.Alert{AlertType: "message", NotificationType: "lowTemp"}, ecobee.AlertMessage},
+	} {
+		if got := tc.alert.Category(); got != tc.want {
+			t.Errorf("Category of %s/%s = %s, want %s", tc.alert.AlertType, tc.alert.NotificationType, got, tc.want)
+		}
+	}
+}
+
+func TestParseAlertCategory(t *testing.T) {
+	for _, tc := range []struct {
+		s       string
+		want    ecobee.AlertCategory
+		wantErr bool
+	}{
+		{s: "reminder", want: ecobee.AlertReminder},
+		{s: "Temperature", want: ecobee.AlertTemperature},
+		{s: "EQUIPMENT", want: ecobee.AlertEquipment},
+		{s: "fault", wantErr: true},
+	} {
+		got, err := ecobee.ParseAlertCategory(tc.s)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ParseAlertCategory(%q) = %q, %v, want %q, error %v", tc.s, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestAlertOpen(t *testing.T) {
+	for _, tc := range []struct {
+		ack  string
+		want bool
+	}{
+		{"", true},
+		{"unacknowledged", true},
+		{"defer", true},
+		{"accept", false},
+		{"decline", false},
+	} {
+		a := ecobee.Alert{Acknowledgement: tc.ack}
+		if got := a.Open(); got != tc.want {
+			t.Errorf("Open with acknowledgement %q = %v, want %v", tc.ack, got, tc.want)
+		}
+	}
+}
+
+func TestAcknowledgeAlert(t *testing.T) {
+	s, c, ct := newRetryServer(t)
+	s.AddAlert("123", ecobee.Alert{AcknowledgeRef: "filter", AlertType: "alert", NotificationType: "furnaceFilter", Text: "Change the filter"})
+	s.AddAlert("123", ecobee.Alert{AcknowledgeRef: "cold", AlertType: "alert", NotificationType: "lowTemp", Text: "It is cold"})
+
+	// Snoozing is sent as "defer", and keeps the alert open.
+	if err := c.AcknowledgeAlert("123", "filter", ecobee.AckSnooze); err != nil {
+		t.Fatalf("AcknowledgeAlert(snooze): %v", err)
+	}
+	alerts, err := c.GetAlerts("123")
+	if err != nil {
+		t.Fatalf("GetAlerts: %v", err)
+	}
+	if len(alerts) != 2 || alerts[0].Acknowledgement != "defer" || !alerts[0].RemindMeLater || !alerts[0].Open() {
+		t.Errorf("alerts = %+v, want filter deferred and open", alerts)
+	}
+
+	if err := c.AcknowledgeAlert("123", "cold", ecobee.AckAccept); err != nil {
+		t.Fatalf("AcknowledgeAlert(accept): %v", err)
+	}
+	alerts = thermostat(t, s, "123").Alerts
+	if len(alerts) != 1 || alerts[0].AcknowledgeRef != "filter" {
+		t.Errorf("alerts = %+v, want only filter", alerts)
+	}
+
+	if err := c.AcknowledgeAlert("123", "nosuch", ecobee.AckAccept); err == nil {
+		t.Error("AcknowledgeAlert of an unknown ref succeeded")
+	}
+
+	// Invalid acknowledgements are rejected without a request.
+	n := ct.count("/1/thermostat")
+	if err := c.AcknowledgeAlert("123", "filter", ecobee.AckUnacknowledged); err == nil {
+		t.Error("AcknowledgeAlert(unacknowledged) succeeded")
+	}
+	if err := c.AcknowledgeAlert("123", "", ecobee.AckAccept); err == nil {
+		t.Error("AcknowledgeAlert without a ref succeeded")
+	}
+	if got := ct.count("/1/thermostat"); got != n {
+		t.Errorf("invalid acknowledgements sent %d requests", got-n)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -34,28 +35,45 @@ import (
 var Scopes = []string{"smartRead", "smartWrite"}
 
 type tokenSource struct {
+	mu                  sync.Mutex
 	token               oauth2.Token
 	cacheFile, clientID string
+
+	// authSem serializes the interactive authorization, which waits
+	// for the user without holding mu.
+	authSem chan struct{}
+
+	// baseURL and hc are used for the PIN and token requests.
+	baseURL string
+	hc      *http.Client
 }
 
-func TokenSource(clientID, cacheFile string) oauth2.TokenSource {
-	return oauth2.ReuseTokenSource(nil, newTokenSource(clientID, cacheFile))
+func TokenSource(clientID, cacheFile string, opts ...ClientOption) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, newTokenSource(clientID, cacheFile, newClientOptions(opts)))
 }
 
-func newTokenSource(clientID, cacheFile string) *tokenSource {
+func newTokenSource(clientID, cacheFile string, co *clientOptions) *tokenSource {
+	ts := &tokenSource{
+		clientID:  clientID,
+		cacheFile: cacheFile,
+		baseURL:   co.baseURL,
+		hc:        co.httpClient(),
+		authSem:   make(chan struct{}, 1),
+	}
 	file, err := os.ReadFile(cacheFile)
 	if err != nil {
 		// no file, corrupted, or other problem: just start with an
 		// empty token.
-		return &tokenSource{clientID: clientID, cacheFile: cacheFile}
+		return ts
 	}
 	var tok oauth2.Token
 	err = json.Unmarshal(file, &tok)
 	if err != nil {
 		// can't unmarshal?  Return an empty token.
-		return &tokenSource{clientID: clientID, cacheFile: cacheFile}
+		return ts
 	}
-	return &tokenSource{clientID: clientID, cacheFile: cacheFile, token: tok}
+	ts.token = tok
+	return ts
 }
 
 func (ts *tokenSource) save() error {
@@ -72,9 +90,25 @@ type PinResponse struct {
 	Code      string `json:"code"`
 }
 
-// Interactive authentication, triggered on initial use of the client
-func (ts *tokenSource) firstAuth() error {
-	pinResponse, err := ts.authorize()
+// Interactive authentication, triggered on initial use of the client.
+// It must be called without holding ts.mu.  Only one caller prompts
+// the user; concurrent callers wait for its token.
+func (ts *tokenSource) firstAuth(ctx context.Context) error {
+	select {
+	case ts.authSem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-ts.authSem }()
+
+	ts.mu.Lock()
+	valid := ts.token.Valid()
+	ts.mu.Unlock()
+	if valid {
+		return nil
+	}
+
+	pinResponse, err := ts.authorize(ctx)
 	if err != nil {
 		return err
 	}
@@ -85,36 +119,38 @@ func (ts *tokenSource) firstAuth() error {
 	if err != nil {
 		return err
 	}
-	return ts.accessToken(pinResponse.Code)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.accessToken(ctx, pinResponse.Code)
 }
 
 // Make a pin request to ecobee and return the pin and code
-func (ts *tokenSource) authorize() (*PinResponse, error) {
+func (ts *tokenSource) authorize(ctx context.Context) (*PinResponse, error) {
 	uv := url.Values{
 		"response_type": {"ecobeePin"},
 		"client_id":     {ts.clientID},
 		"scope":         {strings.Join(Scopes, ",")},
 	}
-	u := url.URL{
-		Scheme:   "https",
-		Host:     "api.ecobee.com",
-		Path:     "authorize",
-		RawQuery: uv.Encode(),
-	}
+	u := endpointURL(ts.baseURL, authorizePath) + "?" + uv.Encode()
 
-	resp, err := http.Get(u.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving response: %s", err)
+		return nil, fmt.Errorf("error creating request: %s", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("invalid server response: %v", resp.Status)
+	resp, err := ts.hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving response: %w", err)
 	}
+	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response: %s", err)
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, newAPIError(endpointURL(ts.baseURL, authorizePath), resp.StatusCode, body)
 	}
 
 	var r PinResponse
@@ -142,40 +178,40 @@ func (tr *tokenResponse) Token() oauth2.Token {
 	return tok
 }
 
-func (ts *tokenSource) accessToken(code string) error {
-	return ts.getToken(url.Values{
+func (ts *tokenSource) accessToken(ctx context.Context, code string) error {
+	return ts.getToken(ctx, url.Values{
 		"grant_type": {"ecobeePin"},
 		"client_id":  {ts.clientID},
 		"code":       {code},
 	})
 }
-func (ts *tokenSource) refreshToken() error {
-	return ts.getToken(url.Values{
+func (ts *tokenSource) refreshToken(ctx context.Context) error {
+	return ts.getToken(ctx, url.Values{
 		"grant_type":    {"refresh_token"},
 		"client_id":     {ts.clientID},
 		"refresh_token": {ts.token.RefreshToken},
 	})
 }
 
-func (ts *tokenSource) getToken(uv url.Values) error {
-	u := url.URL{
-		Scheme:   "https",
-		Host:     "api.ecobee.com",
-		Path:     "token",
-		RawQuery: uv.Encode(),
+func (ts *tokenSource) getToken(ctx context.Context, uv url.Values) error {
+	u := endpointURL(ts.baseURL, tokenPath) + "?" + uv.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %s", err)
 	}
-	resp, err := http.PostForm(u.String(), nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := ts.hc.Do(req)
 	if err != nil {
-		return fmt.Errorf("error POSTing request: %s", err)
+		return fmt.Errorf("error POSTing request: %w", err)
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("invalid server response: %v", resp.Status)
-	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error reading response: %s", err)
+		return fmt.Errorf("error reading response: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return newAPIError(endpointURL(ts.baseURL, tokenPath), resp.StatusCode, body)
 	}
 
 	var r tokenResponse
@@ -196,25 +232,90 @@ func (ts *tokenSource) getToken(uv url.Values) error {
 }
 
 func (ts *tokenSource) Token() (*oauth2.Token, error) {
+	return ts.tokenContext(context.Background())
+}
+
+// tokenContext is Token, with ctx used for any requests needed to
+// refresh or obtain the token.
+func (ts *tokenSource) tokenContext(ctx context.Context) (*oauth2.Token, error) {
+	ts.mu.Lock()
+	if !ts.token.Valid() && len(ts.token.RefreshToken) == 0 {
+		// Don't keep other callers waiting while the user authorizes.
+		ts.mu.Unlock()
+		err := ts.firstAuth(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error on initial authentication: %w", err)
+		}
+		ts.mu.Lock()
+	}
+	defer ts.mu.Unlock()
 	if !ts.token.Valid() {
-		if len(ts.token.RefreshToken) > 0 {
-			err := ts.refreshToken()
-			if err != nil {
-				return nil, fmt.Errorf("error refreshing token: %s", err)
-			}
-		} else {
-			err := ts.firstAuth()
-			if err != nil {
-				return nil, fmt.Errorf("error on initial authentication: %s", err)
-			}
+		err := ts.refreshToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error refreshing token: %w", err)
+		}
+	}
+	tok := ts.token
+	return &tok, nil
+}
+
+// currentAccessToken returns the access token in use.
+func (ts *tokenSource) currentAccessToken() string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.token.AccessToken
+}
+
+// forceRefresh refreshes the access token even though it has not
+// expired locally.  It is used when ecobee rejects a token early.  If
+// the current token is no longer rejected, another request refreshed it
+// already, and it is kept.
+func (ts *tokenSource) forceRefresh(ctx context.Context, rejected string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.token.AccessToken != rejected {
+		return nil
+	}
+	if len(ts.token.RefreshToken) == 0 {
+		return fmt.Errorf("no refresh token")
+	}
+	return ts.refreshToken(ctx)
+}
+
+type transport struct {
+	ts   *tokenSource
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tok, err := t.ts.tokenContext(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
 		}
+		return nil, err
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
 	}
-	return &ts.token, nil
+	// RoundTrippers must not modify the request.
+	r := req.Clone(req.Context())
+	tok.SetAuthHeader(r)
+	return base.RoundTrip(r)
 }
 
 // Client represents the Ecobee API client.
 type Client struct {
 	*http.Client
+
+	baseURL string
+	retry   RetryPolicy
+	limiter *rateLimiter
+	ts      *tokenSource
+
+	zoneMu sync.Mutex
+	zones  map[string]*time.Location
 }
 
 // NewClient creates a Ecobee API client for the specific clientID
@@ -222,20 +323,46 @@ type Client struct {
 // Application Key.
 // (https://www.ecobee.com/consumerportal/index.html#/dev)
 func NewClient(clientID, cacheFile string) *Client {
-	return &Client{oauth2.NewClient(
-		context.Background(), TokenSource(clientID, cacheFile))}
+	return NewClientWithOptions(clientID, cacheFile)
+}
+
+// NewClientWithOptions is like NewClient, but allows the API base URL,
+// HTTP client, transport and user agent to be overridden.  The options
+// apply to the PIN and token requests as well as the API calls.
+func NewClientWithOptions(clientID, cacheFile string, opts ...ClientOption) *Client {
+	co := newClientOptions(opts)
+	hc := co.httpClient()
+	ts := newTokenSource(clientID, cacheFile, co)
+	hc.Transport = &transport{ts: ts, base: hc.Transport}
+	return &Client{
+		Client:  hc,
+		baseURL: co.baseURL,
+		retry:   co.retry,
+		limiter: co.limiter,
+		ts:      ts,
+	}
 }
 
 // Authorize retrieves an ecobee Pin and Code, allowing calling code to present them to the user
 // outside of the ecobee request context.
 // This is useful when non-interactive authorization is required.
 // For example: an app being deployed and authorized using ansible, which does not support interacting with commands.
-func Authorize(clientID string) (*PinResponse, error) {
-	return newTokenSource(clientID, "").authorize()
+func Authorize(clientID string, opts ...ClientOption) (*PinResponse, error) {
+	return AuthorizeContext(context.Background(), clientID, opts...)
+}
+
+// AuthorizeContext is like Authorize, using ctx for the request.
+func AuthorizeContext(ctx context.Context, clientID string, opts ...ClientOption) (*PinResponse, error) {
+	return newTokenSource(clientID, "", newClientOptions(opts)).authorize(ctx)
 }
 
 // SaveToken retreives a new token from ecobee and saves it to the auth cache
 // after a pin/code combination has been added by an ecobee user.
-func SaveToken(clientID string, cacheFile string, code string) error {
-	return newTokenSource(clientID, cacheFile).accessToken(code)
+func SaveToken(clientID string, cacheFile string, code string, opts ...ClientOption) error {
+	return SaveTokenContext(context.Background(), clientID, cacheFile, code, opts...)
+}
+
+// SaveTokenContext is like SaveToken, using ctx for the request.
+func SaveTokenContext(ctx context.Context, clientID string, cacheFile string, code string, opts ...ClientOption) error {
+	return newTokenSource(clientID, cacheFile, newClientOptions(opts)).accessToken(ctx, code)
 }
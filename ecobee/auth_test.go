@@ -0,0 +1,119 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+func TestTokenExpiredReplay(t *testing.T) {
+	s, c, ct := newRetryServer(t)
+	tokens := ct.count("/token")
+
+	// ecobee revokes the token before its local expiry.
+	s.ExpireTokens()
+	if _, err := c.GetThermostat("123"); err != nil {
+		t.Fatalf("GetThermostat with a revoked token: %v", err)
+	}
+	if n := ct.count("/token") - tokens; n != 1 {
+		t.Errorf("refreshed %d times, want 1", n)
+	}
+	if n := ct.count("/1/thermostat"); n != 2 {
+		t.Errorf("sent %d thermostat requests, want the request and one replay", n)
+	}
+
+	// The refreshed token is used from then on.
+	if _, err := c.GetThermostat("123"); err != nil {
+		t.Fatalf("GetThermostat after refresh: %v", err)
+	}
+	if n := ct.count("/token") - tokens; n != 1 {
+		t.Errorf("refreshed %d times, want 1", n)
+	}
+}
+
+func TestTokenExpiredConcurrent(t *testing.T) {
+	const n = 5
+	s, c, ct := newRetryServer(t)
+	tokens := ct.count("/token")
+
+	// All requests are rejected before any of them refreshes the
+	// token.
+	var rejected sync.WaitGroup
+	rejected.Add(n)
+	ct.mu.Lock()
+	ct.after = func(req *http.Request) {
+		if req.URL.Path == "/1/thermostat" && ct.count("/1/thermostat") <= n {
+			rejected.Done()
+			rejected.Wait()
+		}
+	}
+	ct.mu.Unlock()
+	s.ExpireTokens()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetThermostat("123")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetThermostat with a revoked token: %v", err)
+		}
+	}
+	if got := ct.count("/token") - tokens; got != 1 {
+		t.Errorf("refreshed %d times, want 1", got)
+	}
+	if got := ct.count("/1/thermostat"); got != 2*n {
+		t.Errorf("sent %d thermostat requests, want %d requests and as many replays", got, n)
+	}
+}
+
+func TestTokenExpiredTwice(t *testing.T) {
+	s, c, ct := newRetryServer(t)
+	tokens := ct.count("/token")
+
+	// Every token is revoked as soon as it is issued.
+	ct.mu.Lock()
+	ct.after = func(req *http.Request) {
+		if req.URL.Path == "/token" {
+			s.ExpireTokens()
+		}
+	}
+	ct.mu.Unlock()
+	s.ExpireTokens()
+
+	_, err := c.GetThermostat("123")
+	if !errors.Is(err, ecobee.ErrTokenExpired) {
+		t.Errorf("GetThermostat = %v, want ErrTokenExpired", err)
+	}
+	if n := ct.count("/token") - tokens; n != 1 {
+		t.Errorf("refreshed %d times, want 1", n)
+	}
+	if n := ct.count("/1/thermostat"); n != 2 {
+		t.Errorf("sent %d thermostat requests, want the request and one replay", n)
+	}
+}
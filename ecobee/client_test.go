@@ -0,0 +1,615 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/rspier/go-ecobee/ecobee/ecobeetest"
+)
+
+// testThermostat returns a thermostat in Toronto running the "home"
+// climate all week.
+func testThermostat(id, name string) ecobee.Thermostat {
+	schedule := make([][]string, 7)
+	for d := range schedule {
+		for i := 0; i < 48; i++ {
+			schedule[d] = append(schedule[d], "home")
+		}
+	}
+	return ecobee.Thermostat{
+		Identifier: id,
+		Name:       name,
+		Location:   ecobee.Location{TimeZone: "America/Toronto"},
+		Settings:   ecobee.Settings{HvacMode: ecobee.HvacModeHeat},
+		Runtime: ecobee.Runtime{
+			ActualTemperature: 712,
+			ActualHumidity:    40,
+			DesiredFanMode:    "auto",
+		},
+		Program: ecobee.Program{
+			Schedule: schedule,
+			Climates: []ecobee.Climate{
+				{Name: "Home", ClimateRef: "home", HeatTemp: 680, CoolTemp: 750},
+				{Name: "Away", ClimateRef: "away", HeatTemp: 620, CoolTemp: 800},
+			},
+			CurrentClimateRef: "home",
+		},
+	}
+}
+
+// newTestServer starts a fake with thermostats 123 and 456, and returns
+// it with a client authorized against it.
+func newTestServer(t *testing.T, opts ...ecobee.ClientOption) (*ecobeetest.Server, *ecobee.Client) {
+	t.Helper()
+	s := ecobeetest.NewServer()
+	t.Cleanup(s.Close)
+	s.AddThermostat(testThermostat("123", "Home"))
+	s.AddThermostat(testThermostat("456", "Cottage"))
+	c, err := s.NewClient(filepath.Join(t.TempDir(), "authcache"), opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return s, c
+}
+
+// thermostat returns the fake's state of thermostat id.
+func thermostat(t *testing.T, s *ecobeetest.Server, id string) ecobee.Thermostat {
+	t.Helper()
+	th, ok := s.Thermostat(id)
+	if !ok {
+		t.Fatalf("no thermostat %s", id)
+	}
+	return th
+}
+
+// holdEvent returns the hold event of th.
+func holdEvent(t *testing.T, th ecobee.Thermostat) ecobee.Event {
+	t.Helper()
+	for _, e := range th.Events {
+		if e.Type == "hold" {
+			return e
+		}
+	}
+	t.Fatalf("thermostat %s has no hold, events %+v", th.Identifier, th.Events)
+	return ecobee.Event{}
+}
+
+// holdLength returns the time from the start to the end of e.
+func holdLength(t *testing.T, e ecobee.Event) time.Duration {
+	t.Helper()
+	loc, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, err := e.Start(loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	end, err := e.End(loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return end.Sub(start)
+}
+
+func TestHoldTemp(t *testing.T) {
+	s, c := newTestServer(t)
+	before := thermostat(t, s, "123")
+
+	if err := c.HoldTemp("123", 70, 76, 2*time.Hour); err != nil {
+		t.Fatalf("HoldTemp: %v", err)
+	}
+
+	th := thermostat(t, s, "123")
+	e := holdEvent(t, th)
+	if e.HeatHoldTemp != 700 || e.CoolHoldTemp != 760 || !e.IsTemperatureAbsolute || !e.Running {
+		t.Errorf("hold = %+v, want a running absolute hold at 700-760", e)
+	}
+	if d := holdLength(t, e); d < 2*time.Hour-time.Second || d > 2*time.Hour+time.Second {
+		t.Errorf("hold lasts %v, want 2h", d)
+	}
+	if th.Runtime.DesiredHeat != 700 || th.Runtime.DesiredCool != 760 {
+		t.Errorf("desired = %v-%v, want 700-760", th.Runtime.DesiredHeat, th.Runtime.DesiredCool)
+	}
+	if th.ThermostatRev <= before.ThermostatRev {
+		t.Errorf("thermostatRev = %q, want later than %q", th.ThermostatRev, before.ThermostatRev)
+	}
+	if other := thermostat(t, s, "456"); len(other.Events) != 0 {
+		t.Errorf("thermostat 456 events = %+v, want none", other.Events)
+	}
+}
+
+func TestHoldTempRejected(t *testing.T) {
+	s, c := newTestServer(t)
+	before := thermostat(t, s, "123")
+
+	for _, tc := range []struct {
+		heat, cool float64
+	}{
+		{95, 100}, // heat above the limit
+		{50, 55},  // cool below the limit
+		{75, 70},  // heat above cool
+	} {
+		if err := c.HoldTemp("123", tc.heat, tc.cool, time.Hour); err == nil {
+			t.Errorf("HoldTemp(%v, %v) succeeded, want error", tc.heat, tc.cool)
+		}
+	}
+	if th := thermostat(t, s, "123"); th.ThermostatRev != before.ThermostatRev || len(th.Events) != 0 {
+		t.Errorf("thermostat changed by rejected holds: rev %q, events %+v", th.ThermostatRev, th.Events)
+	}
+}
+
+func TestRunFan(t *testing.T) {
+	s, c := newTestServer(t)
+
+	if err := c.RunFan("123", 30*time.Minute); err != nil {
+		t.Fatalf("RunFan: %v", err)
+	}
+
+	th := thermostat(t, s, "123")
+	e := holdEvent(t, th)
+	if e.Fan != "on" || e.IsTemperatureAbsolute || e.IsTemperatureRelative {
+		t.Errorf("hold = %+v, want a fan hold without temperatures", e)
+	}
+	if d := holdLength(t, e); d < 30*time.Minute-time.Second || d > 30*time.Minute+time.Second {
+		t.Errorf("hold lasts %v, want 30m", d)
+	}
+	if th.Runtime.DesiredFanMode != "on" {
+		t.Errorf("desiredFanMode = %q, want on", th.Runtime.DesiredFanMode)
+	}
+	// The setpoints stay those of the current climate.
+	if th.Runtime.DesiredHeat != 680 || th.Runtime.DesiredCool != 750 {
+		t.Errorf("desired = %v-%v, want 680-750", th.Runtime.DesiredHeat, th.Runtime.DesiredCool)
+	}
+}
+
+func TestResumeProgram(t *testing.T) {
+	s, c := newTestServer(t)
+	if err := c.HoldTemp("123", 70, 76, time.Hour); err != nil {
+		t.Fatalf("HoldTemp: %v", err)
+	}
+
+	if err := c.ResumeProgram("123", true); err != nil {
+		t.Fatalf("ResumeProgram: %v", err)
+	}
+
+	th := thermostat(t, s, "123")
+	if len(th.Events) != 0 {
+		t.Errorf("events = %+v, want none", th.Events)
+	}
+	if th.Runtime.DesiredHeat != 680 || th.Runtime.DesiredCool != 750 {
+		t.Errorf("desired = %v-%v, want 680-750", th.Runtime.DesiredHeat, th.Runtime.DesiredCool)
+	}
+}
+
+func TestHoldClimate(t *testing.T) {
+	s, c, ct := newRetryServer(t)
+
+	if err := c.HoldClimate("123", "Away", ecobee.HoldOptions{Type: ecobee.HoldIndefinite}); err != nil {
+		t.Fatalf("HoldClimate: %v", err)
+	}
+	e := holdEvent(t, thermostat(t, s, "123"))
+	if e.HoldClimateRef != "away" || !e.Running {
+		t.Errorf("hold = %+v, want a running hold at away", e)
+	}
+	// One GET for the climates and time zone, one POST for the hold.
+	if n := ct.count("/1/thermostat"); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+	// The climate sets the temperatures and fan, so only its ref and
+	// the hold type are sent.
+	var req struct {
+		Functions []struct {
+			Params map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal([]byte(ct.lastPost()), &req); err != nil || len(req.Functions) != 1 {
+		t.Fatalf("request %s: %v, want one function", ct.lastPost(), err)
+	}
+	want := map[string]interface{}{"holdClimateRef": "away", "holdType": "indefinite"}
+	if p := req.Functions[0].Params; !reflect.DeepEqual(p, want) {
+		t.Errorf("setHold params = %v, want %v", p, want)
+	}
+
+	if err := c.HoldClimate("123", "sleep", ecobee.HoldFor(time.Hour)); err == nil {
+		t.Error("HoldClimate(sleep) succeeded, want unknown climate")
+	}
+	if n := ct.count("/1/thermostat"); n != 3 {
+		t.Errorf("sent %d requests in all, want 3", n)
+	}
+}
+
+func TestGetThermostatSummary(t *testing.T) {
+	s, c := newTestServer(t)
+	s.SetEquipmentStatus("123", "fan", "compCool1")
+	sel := ecobee.Selection{
+		SelectionType:          ecobee.SelectionTypeRegistered,
+		IncludeEquipmentStatus: true,
+	}
+
+	tsm, err := c.GetThermostatSummary(sel)
+	if err != nil {
+		t.Fatalf("GetThermostatSummary: %v", err)
+	}
+	if len(tsm) != 2 {
+		t.Fatalf("got %d summaries, want 2: %+v", len(tsm), tsm)
+	}
+	ts := tsm["123"]
+	if ts.Name != "Home" || !ts.Connected {
+		t.Errorf("summary = %+v, want connected thermostat Home", ts)
+	}
+	want := ecobee.EquipmentStatus{Fan: true, CompCool1: true}
+	if ts.EquipmentStatus != want {
+		t.Errorf("equipment = %+v, want %+v", ts.EquipmentStatus, want)
+	}
+	if es := tsm["456"].EquipmentStatus; es != (ecobee.EquipmentStatus{}) {
+		t.Errorf("456 equipment = %+v, want none running", es)
+	}
+	th := thermostat(t, s, "123")
+	if ts.ThermostatRevision != th.ThermostatRev || ts.RuntimeRevision != th.Runtime.RuntimeRev {
+		t.Errorf("revisions = %s/%s, want %s/%s", ts.ThermostatRevision, ts.RuntimeRevision, th.ThermostatRev, th.Runtime.RuntimeRev)
+	}
+
+	// Changes show up as new revisions.
+	if err := c.HoldTemp("123", 70, 76, time.Hour); err != nil {
+		t.Fatalf("HoldTemp: %v", err)
+	}
+	after, err := c.GetThermostatSummary(sel)
+	if err != nil {
+		t.Fatalf("GetThermostatSummary: %v", err)
+	}
+	if after["123"].ThermostatRevision == ts.ThermostatRevision {
+		t.Errorf("thermostatRevision unchanged after hold: %s", ts.ThermostatRevision)
+	}
+	if after["456"].ThermostatRevision != tsm["456"].ThermostatRevision {
+		t.Errorf("456 thermostatRevision changed: %s -> %s", tsm["456"].ThermostatRevision, after["456"].ThermostatRevision)
+	}
+}
+
+func TestUpdateProgram(t *testing.T) {
+	s, c := newTestServer(t)
+
+	p, err := c.GetProgram("123")
+	if err != nil {
+		t.Fatalf("GetProgram: %v", err)
+	}
+	if p.CurrentClimateRef != "home" {
+		t.Fatalf("currentClimateRef = %q, want home", p.CurrentClimateRef)
+	}
+	p.Schedule[0][0] = "away"
+	if err := c.UpdateProgram("123", *p); err != nil {
+		t.Fatalf("UpdateProgram: %v", err)
+	}
+	got := thermostat(t, s, "123").Program
+	if got.Schedule[0][0] != "away" || got.CurrentClimateRef != "home" {
+		t.Errorf("program = %v, current %q; want Monday midnight away, current home", got.Schedule[0][:2], got.CurrentClimateRef)
+	}
+}
+
+func TestUpdateProgramRejected(t *testing.T) {
+	s, c := newTestServer(t)
+	before := thermostat(t, s, "123")
+
+	base := before.Program
+	base.CurrentClimateRef = ""
+	extra := base
+	extra.Schedule = append(append([][]string{}, extra.Schedule...), extra.Schedule[0])
+	short := base
+	short.Schedule = append([][]string{}, short.Schedule...)
+	short.Schedule[2] = short.Schedule[2][:47]
+	unknown := base
+	unknown.Schedule = append([][]string{}, unknown.Schedule...)
+	unknown.Schedule[6] = append(append([]string{}, unknown.Schedule[6][:47]...), "sleep")
+
+	for _, tc := range []struct {
+		name string
+		p    ecobee.Program
+	}{
+		{"8 days", extra},
+		{"47 slots", short},
+		{"unknown climate", unknown},
+		{"current climate", before.Program},
+	} {
+		// UpdateThermostat sends the program as is, leaving the
+		// checks to the server.
+		err := c.UpdateThermostat(ecobee.UpdateThermostatRequest{
+			Selection:  ecobee.Selection{SelectionType: ecobee.SelectionTypeThermostats, SelectionMatch: "123"},
+			Thermostat: &ecobee.ThermostatUpdate{Program: &tc.p},
+		})
+		var ae *ecobee.APIError
+		if !errors.As(err, &ae) || ae.Code != 7 {
+			t.Errorf("%s: UpdateThermostat = %v, want validation error", tc.name, err)
+		}
+	}
+	if th := thermostat(t, s, "123"); th.ThermostatRev != before.ThermostatRev {
+		t.Errorf("thermostatRev = %q after rejected programs, want %q", th.ThermostatRev, before.ThermostatRev)
+	}
+}
+
+// countingTransport counts the requests sent to each path, and keeps
+// the bodies of POST requests.  It fails the first dialFailures of them
+// as if the server were unreachable, and calls after, if set, once each
+// response is received.
+type countingTransport struct {
+	mu           sync.Mutex
+	counts       map[string]int
+	posts        []string
+	dialFailures int
+	after        func(req *http.Request)
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Method == http.MethodPost && req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	t.mu.Lock()
+	if t.counts == nil {
+		t.counts = map[string]int{}
+	}
+	t.counts[req.URL.Path]++
+	if body != nil {
+		t.posts = append(t.posts, string(body))
+	}
+	fail := t.dialFailures > 0
+	if fail {
+		t.dialFailures--
+	}
+	after := t.after
+	t.mu.Unlock()
+	if fail {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if after != nil {
+		after(req)
+	}
+	return resp, err
+}
+
+func (t *countingTransport) count(path string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.counts[path]
+}
+
+// lastPost returns the body of the last POST request.
+func (t *countingTransport) lastPost() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.posts) == 0 {
+		return ""
+	}
+	return t.posts[len(t.posts)-1]
+}
+
+// newRetryServer is newTestServer with a client retrying quickly, whose
+// requests are counted by the returned transport.
+func newRetryServer(t *testing.T) (*ecobeetest.Server, *ecobee.Client, *countingTransport) {
+	t.Helper()
+	ct := &countingTransport{}
+	s, c := newTestServer(t,
+		ecobee.WithTransport(ct),
+		ecobee.WithRetryPolicy(ecobee.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}))
+	return s, c, ct
+}
+
+func TestRetryGet(t *testing.T) {
+	s, c, ct := newRetryServer(t)
+
+	s.FailRequests(2)
+	if _, err := c.GetThermostat("123"); err != nil {
+		t.Fatalf("GetThermostat after 2 failures: %v", err)
+	}
+	if n := ct.count("/1/thermostat"); n != 3 {
+		t.Errorf("sent %d requests, want 3", n)
+	}
+
+	// Attempts are limited by the policy.
+	s.FailRequests(3)
+	_, err := c.GetThermostat("123")
+	var ae *ecobee.APIError
+	if !errors.As(err, &ae) || ae.HTTPStatus != http.StatusServiceUnavailable {
+		t.Errorf("GetThermostat after 3 failures = %v, want HTTP 503", err)
+	}
+	if n := ct.count("/1/thermostat"); n != 6 {
+		t.Errorf("sent %d requests, want 6", n)
+	}
+}
+
+func TestNoRetryNotIdempotent(t *testing.T) {
+	s, c, ct := newRetryServer(t)
+
+	s.FailRequests(1)
+	if _, err := c.UpdateGroups([]ecobee.Group{{GroupName: "Building", Thermostats: []string{"123"}}}); err == nil {
+		t.Error("UpdateGroups succeeded, want the failure")
+	}
+	if n := ct.count("/1/group"); n != 1 {
+		t.Errorf("UpdateGroups sent %d requests, want 1", n)
+	}
+
+	s.FailRequests(1)
+	sel := ecobee.Selection{SelectionType: ecobee.SelectionTypeThermostats, SelectionMatch: "123"}
+	day := time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := c.CreateRuntimeReportJob(sel, day, day, []string{"zoneAveTemp"}, false); err == nil {
+		t.Error("CreateRuntimeReportJob succeeded, want the failure")
+	}
+	if n := ct.count("/1/runtimeReportJob/create"); n != 1 {
+		t.Errorf("CreateRuntimeReportJob sent %d requests, want 1", n)
+	}
+
+	// Requests that never reached the server are retried.
+	ct.mu.Lock()
+	ct.dialFailures = 1
+	ct.mu.Unlock()
+	if _, err := c.CreateRuntimeReportJob(sel, day, day, []string{"zoneAveTemp"}, false); err != nil {
+		t.Errorf("CreateRuntimeReportJob after a dial error: %v", err)
+	}
+	if n := ct.count("/1/runtimeReportJob/create"); n != 3 {
+		t.Errorf("CreateRuntimeReportJob sent %d requests in all, want 3", n)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	_, c := newTestServer(t, ecobee.WithRateLimit(20, 1))
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := c.GetThermostat("123"); err != nil {
+			t.Fatalf("GetThermostat: %v", err)
+		}
+	}
+	// The first request is free, the other 3 wait 50ms each.
+	if d := time.Since(start); d < 140*time.Millisecond {
+		t.Errorf("4 requests at 20/s took %v, want at least 150ms", d)
+	}
+}
+
+func TestGetRuntimeReport(t *testing.T) {
+	s := ecobeetest.NewServer()
+	t.Cleanup(s.Close)
+	th := testThermostat("123", "Home")
+	th.RemoteSensors = []ecobee.RemoteSensor{{
+		ID:         "rs:100",
+		Name:       "Bedroom",
+		Capability: []ecobee.RemoteSensorCapability{{ID: "1", Type: "temperature", Value: "665"}},
+	}}
+	s.AddThermostat(th)
+	s.AddThermostat(testThermostat("456", "Cottage"))
+	ct := &countingTransport{}
+	c, err := s.NewClient(filepath.Join(t.TempDir(), "authcache"), ecobee.WithTransport(ct))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	start := time.Date(2017, 12, 1, 12, 0, 0, 0, time.UTC)
+	r, err := c.GetRuntimeReport("456,123", start, start.Add(time.Hour), []string{"zoneAveTemp"}, true)
+	if err != nil {
+		t.Fatalf("GetRuntimeReport: %v", err)
+	}
+	if len(r.Rows) != 24 || r.Rows[0].Thermostat != "456" || r.Rows[12].Thermostat != "123" {
+		t.Fatalf("got %d rows, want 12 of 456 then 12 of 123", len(r.Rows))
+	}
+	if row := r.Rows[12]; !row.Time.Equal(start) || row.Time.Location().String() != "America/Toronto" || row.Values["zoneAveTemp"] != "71.2" {
+		t.Errorf("first row of 123 = %+v, want 71.2 at %v in Toronto", row, start)
+	}
+	if len(r.SensorRows) != 24 || r.SensorRows[12].Values["rs:100:1"] != "66.5" {
+		t.Errorf("got %d sensor rows, want 24 with rs:100:1 of 123 at 66.5", len(r.SensorRows))
+	}
+	// The batch's time zones take a single request.
+	if n := ct.count("/1/thermostat"); n != 1 {
+		t.Errorf("sent %d thermostat requests, want 1", n)
+	}
+
+	// and are cached after that.
+	if _, err := c.GetRuntimeReport("123", start, start.Add(time.Hour), []string{"zoneAveTemp"}, false); err != nil {
+		t.Fatalf("GetRuntimeReport: %v", err)
+	}
+	if n := ct.count("/1/thermostat"); n != 1 {
+		t.Errorf("sent %d thermostat requests in all, want 1", n)
+	}
+}
+
+func TestUpdateSettings(t *testing.T) {
+	s, c, ct := newRetryServer(t)
+	if err := c.UpdateSettingsFields("123", map[string]interface{}{"useCelsius": true, "fanMinOnTime": 10}); err != nil {
+		t.Fatalf("UpdateSettingsFields: %v", err)
+	}
+	old, err := c.GetSettings("123")
+	if err != nil {
+		t.Fatalf("GetSettings: %v", err)
+	}
+	if !old.UseCelsius || old.FanMinOnTime != 10 {
+		t.Fatalf("settings = %+v, want useCelsius and fanMinOnTime 10", old)
+	}
+
+	// Unchanged settings send nothing.
+	n := ct.count("/1/thermostat")
+	if err := c.UpdateSettings("123", *old, *old); err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	if got := ct.count("/1/thermostat"); got != n {
+		t.Errorf("UpdateSettings without changes sent %d requests", got-n)
+	}
+
+	// A concurrent change of another setting is kept.
+	if err := c.UpdateSettingsFields("123", map[string]interface{}{"hvacMode": "cool"}); err != nil {
+		t.Fatalf("UpdateSettingsFields: %v", err)
+	}
+	new := *old
+	new.UseCelsius = false
+	new.FanMinOnTime = 0
+	if err := c.UpdateSettings("123", *old, new); err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	got := thermostat(t, s, "123").Settings
+	if got.UseCelsius || got.FanMinOnTime != 0 || got.HvacMode != ecobee.HvacModeCool {
+		t.Errorf("settings = useCelsius %v, fanMinOnTime %d, hvacMode %s, want false, 0, cool", got.UseCelsius, got.FanMinOnTime, got.HvacMode)
+	}
+}
+
+func TestSetHvacMode(t *testing.T) {
+	s, c, ct := newRetryServer(t)
+	if err := c.UpdateSettingsFields("123", map[string]interface{}{"heatStages": 1}); err != nil {
+		t.Fatalf("UpdateSettingsFields: %v", err)
+	}
+
+	// Unsupported modes are rejected after fetching the settings, and
+	// unknown ones before, without changing anything.
+	for _, tc := range []struct {
+		mode         ecobee.HvacMode
+		wantRequests int
+	}{
+		{ecobee.HvacModeCool, 1},
+		{ecobee.HvacModeAuto, 1},
+		{ecobee.HvacModeAuxHeatOnly, 1},
+		{"emergency", 0},
+	} {
+		n := ct.count("/1/thermostat")
+		if err := c.SetHvacMode("123", tc.mode); err == nil {
+			t.Errorf("SetHvacMode(%s) succeeded", tc.mode)
+		}
+		if got := ct.count("/1/thermostat") - n; got != tc.wantRequests {
+			t.Errorf("SetHvacMode(%s) sent %d requests, want %d", tc.mode, got, tc.wantRequests)
+		}
+	}
+	if m := thermostat(t, s, "123").Settings.HvacMode; m != ecobee.HvacModeHeat {
+		t.Errorf("hvacMode = %s, want heat", m)
+	}
+
+	if err := c.SetHvacMode("123", "OFF"); err != nil {
+		t.Fatalf("SetHvacMode(OFF): %v", err)
+	}
+	if m := thermostat(t, s, "123").Settings.HvacMode; m != ecobee.HvacModeOff {
+		t.Errorf("hvacMode = %s, want off", m)
+	}
+}
@@ -0,0 +1,199 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// climateFields is the Climate type without its JSON methods.
+type climateFields Climate
+
+// UnmarshalJSON decodes a climate, keeping fields unknown to Climate
+// so that MarshalJSON writes them back.
+func (c *Climate) UnmarshalJSON(b []byte) error {
+	var f climateFields
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+	known, err := toJSONMap(f)
+	if err != nil {
+		return err
+	}
+	for k := range known {
+		delete(all, k)
+	}
+	*c = Climate(f)
+	c.extra = nil
+	if len(all) > 0 {
+		c.extra = all
+	}
+	return nil
+}
+
+// MarshalJSON encodes a climate, including any unknown fields it was
+// decoded with.
+func (c Climate) MarshalJSON() ([]byte, error) {
+	j, err := json.Marshal(climateFields(c))
+	if err != nil || len(c.extra) == 0 {
+		return j, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(j, &all); err != nil {
+		return nil, err
+	}
+	for k, v := range c.extra {
+		if _, ok := all[k]; !ok {
+			all[k] = v
+		}
+	}
+	return json.Marshal(all)
+}
+
+// ClimateSensor returns the entry for sensor s in Climate.Sensors.
+// Climates refer to a sensor's temperature capability.
+func ClimateSensor(s RemoteSensor) (RemoteSensor, error) {
+	for _, c := range s.Capability {
+		if c.Type == "temperature" {
+			return RemoteSensor{ID: s.ID + ":" + c.ID, Name: s.Name}, nil
+		}
+	}
+	return RemoteSensor{}, fmt.Errorf("sensor %s has no temperature capability", s.Name)
+}
+
+// UpdateClimate changes the climate with climateRef ref on thermostat
+// by calling fn with it, then writes the program back.  Fields fn
+// doesn't change, including ones unknown to this package, are kept.
+func (c *Client) UpdateClimate(thermostat, ref string, fn func(*Climate) error) error {
+	return c.UpdateClimateContext(context.Background(), thermostat, ref, fn)
+}
+
+// UpdateClimateContext is like UpdateClimate, using ctx for the
+// request.
+func (c *Client) UpdateClimateContext(ctx context.Context, thermostat, ref string, fn func(*Climate) error) error {
+	p, err := c.GetProgramContext(ctx, thermostat)
+	if err != nil {
+		return err
+	}
+	cl := p.Climate(ref)
+	if cl == nil {
+		return fmt.Errorf("thermostat %s has no climate %q (known: %v)", thermostat, ref, p.ClimateRefs())
+	}
+	if err := fn(cl); err != nil {
+		return err
+	}
+	cl.ClimateRef = ref
+	if err := checkClimate(p, cl); err != nil {
+		return err
+	}
+	return c.UpdateProgramContext(ctx, thermostat, *p)
+}
+
+// AddClimate adds climate cl to thermostat.  cl.ClimateRef should be
+// empty; ecobee assigns one.
+func (c *Client) AddClimate(thermostat string, cl Climate) error {
+	return c.AddClimateContext(context.Background(), thermostat, cl)
+}
+
+// AddClimateContext is like AddClimate, using ctx for the request.
+func (c *Client) AddClimateContext(ctx context.Context, thermostat string, cl Climate) error {
+	p, err := c.GetProgramContext(ctx, thermostat)
+	if err != nil {
+		return err
+	}
+	if cl.ClimateRef != "" && p.Climate(cl.ClimateRef) != nil {
+		return fmt.Errorf("thermostat %s already has climate %q", thermostat, cl.ClimateRef)
+	}
+	p.Climates = append(p.Climates, cl)
+	if err := checkClimate(p, &p.Climates[len(p.Climates)-1]); err != nil {
+		return err
+	}
+	return c.UpdateProgramContext(ctx, thermostat, *p)
+}
+
+// DeleteClimate deletes the climate with climateRef ref from
+// thermostat.  It refuses to delete a climate used by the schedule.
+func (c *Client) DeleteClimate(thermostat, ref string) error {
+	return c.DeleteClimateContext(context.Background(), thermostat, ref)
+}
+
+// DeleteClimateContext is like DeleteClimate, using ctx for the
+// request.
+func (c *Client) DeleteClimateContext(ctx context.Context, thermostat, ref string) error {
+	p, err := c.GetProgramContext(ctx, thermostat)
+	if err != nil {
+		return err
+	}
+	if p.Climate(ref) == nil {
+		return fmt.Errorf("thermostat %s has no climate %q (known: %v)", thermostat, ref, p.ClimateRefs())
+	}
+	if days := p.scheduledDays(ref); len(days) > 0 {
+		return fmt.Errorf("climate %q is used by the schedule on %s", ref, strings.Join(days, ", "))
+	}
+	var cs []Climate
+	for _, cl := range p.Climates {
+		if cl.ClimateRef != ref {
+			cs = append(cs, cl)
+		}
+	}
+	p.Climates = cs
+	return c.UpdateProgramContext(ctx, thermostat, *p)
+}
+
+// scheduledDays returns the days on which the schedule uses ref.  Rows
+// beyond the seven days of the week are ignored.
+func (p *Program) scheduledDays(ref string) []string {
+	var days []string
+	for i := 0; i < len(p.Schedule) && i < len(Weekdays); i++ {
+		for _, r := range p.Schedule[i] {
+			if r == ref {
+				days = append(days, Weekdays[i].String())
+				break
+			}
+		}
+	}
+	return days
+}
+
+// checkClimate checks a new or changed climate cl in p.
+func checkClimate(p *Program, cl *Climate) error {
+	if cl.Name == "" {
+		return fmt.Errorf("climate name must not be empty")
+	}
+	for i := range p.Climates {
+		o := &p.Climates[i]
+		if o != cl && strings.EqualFold(o.Name, cl.Name) {
+			return fmt.Errorf("there already is a climate named %q", o.Name)
+		}
+	}
+	if cl.CoolTemp < cl.HeatTemp {
+		return fmt.Errorf("climate %s: heat %s must be below cool %s", cl.Name, cl.HeatTemp, cl.CoolTemp)
+	}
+	for _, f := range []string{cl.HeatFan, cl.CoolFan} {
+		switch f {
+		case "", "auto", "on":
+		default:
+			return fmt.Errorf("invalid fan mode %q, want auto or on", f)
+		}
+	}
+	return nil
+}
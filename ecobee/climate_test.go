@@ -0,0 +1,47 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScheduledDays(t *testing.T) {
+	row := func(ref string) []string { return []string{"home", ref} }
+	for _, tc := range []struct {
+		name     string
+		schedule [][]string
+		want     []string
+	}{
+		{"unused", [][]string{row("home"), row("home")}, nil},
+		{"short", [][]string{row("home"), row("away")}, []string{"Tuesday"}},
+		{
+			"week",
+			[][]string{row("away"), row("home"), row("home"), row("home"), row("home"), row("home"), row("away")},
+			[]string{"Monday", "Sunday"},
+		},
+		{
+			"extra rows",
+			[][]string{row("home"), row("home"), row("home"), row("home"), row("home"), row("home"), row("away"), row("away"), row("away")},
+			[]string{"Sunday"},
+		},
+	} {
+		p := Program{Schedule: tc.schedule}
+		if got := p.scheduledDays("away"); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: scheduledDays = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
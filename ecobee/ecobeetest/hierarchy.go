@@ -0,0 +1,288 @@
+package ecobeetest
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+// hierarchySet is a management set.  Thermostats not assigned to any
+// set are in the root set "/".
+type hierarchySet struct {
+	ref         string
+	thermostats []string
+}
+
+type hierarchyUser struct {
+	user       ecobee.HierarchyUser
+	privileges []ecobee.HierarchyPrivilege
+}
+
+// AddHierarchyUser adds an EMS user with privileges to the model.
+func (s *Server) AddHierarchyUser(u ecobee.HierarchyUser, privileges ...ecobee.HierarchyPrivilege) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range privileges {
+		privileges[i].UserName = u.UserName
+	}
+	s.users = append(s.users, hierarchyUser{user: u, privileges: privileges})
+}
+
+// inSet reports whether the set at p is the one at root or one of its
+// descendants.
+func inSet(p, root string) bool {
+	return root == "/" || p == root || strings.HasPrefix(p, root+"/")
+}
+
+// setOf returns the path of the set thermostat id is assigned to.
+func (s *Server) setOf(id string) string {
+	for p, hs := range s.sets {
+		for _, t := range hs.thermostats {
+			if t == id {
+				return p
+			}
+		}
+	}
+	return "/"
+}
+
+func (s *Server) hierarchySet(p string) *hierarchySet {
+	if s.sets == nil {
+		s.sets = map[string]*hierarchySet{"/": {ref: "root"}}
+	}
+	return s.sets[p]
+}
+
+// describeSet returns the set at p with its children, and theirs if
+// recursive.
+func (s *Server) describeSet(p string, recursive bool) ecobee.HierarchySet {
+	hs := s.hierarchySet(p)
+	set := ecobee.HierarchySet{
+		SetName:     path.Base(p),
+		SetRef:      hs.ref,
+		SetPath:     p,
+		Children:    []ecobee.HierarchySet{},
+		Thermostats: append([]string{}, hs.thermostats...),
+	}
+	if p == "/" {
+		set.SetName = "Root"
+		set.Thermostats = []string{}
+		for _, th := range s.thermostats {
+			if s.setOf(th.t.Identifier) == "/" {
+				set.Thermostats = append(set.Thermostats, th.t.Identifier)
+			}
+		}
+	} else {
+		set.ParentPath = path.Dir(p)
+	}
+	var children []string
+	for c := range s.sets {
+		if c != "/" && path.Dir(c) == p {
+			children = append(children, c)
+		}
+	}
+	sort.Strings(children)
+	for _, c := range children {
+		child := s.describeSet(c, recursive)
+		if !recursive {
+			child.Children = []ecobee.HierarchySet{}
+		}
+		set.Children = append(set.Children, child)
+	}
+	return set
+}
+
+// readHierarchyRequest decodes the body of a hierarchy request into v.
+func readHierarchyRequest(r *http.Request, v interface{}) error {
+	if r.Method != http.MethodPost {
+		return errorf(codeProcessingError, "method %s not allowed", r.Method)
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return errorf(codeSerialization, "invalid json: %v", err)
+	}
+	return nil
+}
+
+func (s *Server) handleHierarchySet(w http.ResponseWriter, r *http.Request) {
+	var req ecobee.HierarchySetRequest
+	if err := readHierarchyRequest(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp, err := s.hierarchySetOp(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) hierarchySetOp(req ecobee.HierarchySetRequest) (ecobee.HierarchySetResponse, error) {
+	resp := ecobee.HierarchySetResponse{Sets: []ecobee.HierarchySet{}}
+	switch req.Operation {
+	case "list":
+		if s.hierarchySet(req.SetPath) == nil {
+			return resp, errorf(codeValidation, "unknown set %q", req.SetPath)
+		}
+		resp.Sets = append(resp.Sets, s.describeSet(req.SetPath, req.Recursive))
+	case "add":
+		if s.hierarchySet(req.ParentPath) == nil {
+			return resp, errorf(codeValidation, "unknown set %q", req.ParentPath)
+		}
+		p := path.Join(req.ParentPath, req.SetName)
+		if req.SetName == "" || s.sets[p] != nil {
+			return resp, errorf(codeDuplicateData, "set %q already exists", p)
+		}
+		s.setSeq++
+		s.sets[p] = &hierarchySet{ref: fmt.Sprintf("set%d", s.setSeq)}
+	case "remove":
+		if req.SetPath == "/" || s.hierarchySet(req.SetPath) == nil {
+			return resp, errorf(codeValidation, "unknown set %q", req.SetPath)
+		}
+		for p, hs := range s.sets {
+			if p != req.SetPath && inSet(p, req.SetPath) || p == req.SetPath && len(hs.thermostats) > 0 {
+				return resp, errorf(codeValidation, "set %q is not empty", req.SetPath)
+			}
+		}
+		delete(s.sets, req.SetPath)
+	case "move":
+		if req.SetPath == "/" || s.hierarchySet(req.SetPath) == nil {
+			return resp, errorf(codeValidation, "unknown set %q", req.SetPath)
+		}
+		if s.sets[req.ToPath] == nil || inSet(req.ToPath, req.SetPath) {
+			return resp, errorf(codeValidation, "can't move set %q to %q", req.SetPath, req.ToPath)
+		}
+		to := path.Join(req.ToPath, path.Base(req.SetPath))
+		if s.sets[to] != nil {
+			return resp, errorf(codeDuplicateData, "set %q already exists", to)
+		}
+		moved := map[string]*hierarchySet{}
+		for p, hs := range s.sets {
+			if inSet(p, req.SetPath) {
+				moved[to+strings.TrimPrefix(p, req.SetPath)] = hs
+				delete(s.sets, p)
+			}
+		}
+		for p, hs := range moved {
+			s.sets[p] = hs
+		}
+	default:
+		return resp, errorf(codeValidation, "unsupported set operation %q", req.Operation)
+	}
+	return resp, nil
+}
+
+func (s *Server) handleHierarchyThermostat(w http.ResponseWriter, r *http.Request) {
+	var req ecobee.HierarchyThermostatRequest
+	if err := readHierarchyRequest(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.hierarchyThermostatOp(req); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeStatus(w, http.StatusOK, codeSuccess, "")
+}
+
+func (s *Server) hierarchyThermostatOp(req ecobee.HierarchyThermostatRequest) error {
+	to := req.SetPath
+	switch req.Operation {
+	case "assign":
+	case "move":
+		to = req.ToPath
+	default:
+		return errorf(codeValidation, "unsupported thermostat operation %q", req.Operation)
+	}
+	if s.hierarchySet(to) == nil {
+		return errorf(codeValidation, "unknown set %q", to)
+	}
+	ids := strings.Split(req.Thermostats, ",")
+	for _, id := range ids {
+		if s.find(id) == nil {
+			return errorf(codeValidation, "unknown thermostat %q", id)
+		}
+		if req.Operation == "move" && s.setOf(id) != req.FromPath {
+			return errorf(codeValidation, "thermostat %s is not in set %q", id, req.FromPath)
+		}
+	}
+	for _, id := range ids {
+		if from := s.sets[s.setOf(id)]; from != nil {
+			var kept []string
+			for _, t := range from.thermostats {
+				if t != id {
+					kept = append(kept, t)
+				}
+			}
+			from.thermostats = kept
+		}
+		if to != "/" {
+			s.sets[to].thermostats = append(s.sets[to].thermostats, id)
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleHierarchyUser(w http.ResponseWriter, r *http.Request) {
+	var req ecobee.HierarchyUserRequest
+	if err := readHierarchyRequest(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Operation != "list" {
+		writeError(w, errorf(codeValidation, "unsupported user operation %q", req.Operation))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := ecobee.HierarchyUserResponse{
+		Users:      []ecobee.HierarchyUser{},
+		Privileges: []ecobee.HierarchyPrivilege{},
+	}
+	for _, u := range s.users {
+		found := false
+		for _, p := range u.privileges {
+			if p.SetPath == req.SetPath || req.Recursive && inSet(p.SetPath, req.SetPath) {
+				found = true
+				if req.IncludePrivileges {
+					resp.Privileges = append(resp.Privileges, p)
+				}
+			}
+		}
+		if found {
+			resp.Users = append(resp.Users, u.user)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
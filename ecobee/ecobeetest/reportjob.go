@@ -0,0 +1,179 @@
+package ecobeetest
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+// reportJob is a runtime report job.  Its files are written when it is
+// created, and published once it completes.
+type reportJob struct {
+	job   ecobee.ReportJob
+	polls int
+	files []string
+}
+
+// handleReportJobCreate starts a job reporting the thermostats'
+// current runtime state for every interval of the requested days.
+// Sensor readings follow the requested columns, named by sensor ID.
+func (s *Server) handleReportJobCreate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req ecobee.RuntimeReportJobRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, errorf(codeSerialization, "invalid json: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ths, err := s.match(req.Selection)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	start, err := time.Parse(ecobee.DateLayout, req.StartDate)
+	if err != nil {
+		writeError(w, errorf(codeValidation, "invalid startDate %q", req.StartDate))
+		return
+	}
+	end, err := time.Parse(ecobee.DateLayout, req.EndDate)
+	if err != nil || end.Before(start) {
+		writeError(w, errorf(codeValidation, "invalid endDate %q", req.EndDate))
+		return
+	}
+	end = end.Add(24*time.Hour - ecobee.RuntimeInterval)
+	columns := strings.Split(req.Columns, ",")
+
+	if s.jobs == nil {
+		s.jobs = map[string]*reportJob{}
+		s.files = map[string][]byte{}
+	}
+	s.jobSeq++
+	id := fmt.Sprintf("job%d", s.jobSeq)
+	j := &reportJob{
+		job:   ecobee.ReportJob{JobID: id, Status: ecobee.JobQueued},
+		polls: s.ReportJobPolls,
+	}
+	for _, th := range ths {
+		header, values := append([]string{"date", "time"}, columns...), runtimeValues(th, columns)
+		if req.IncludeSensors {
+			sensors, readings := sensorReadings(th)
+			for _, rs := range sensors {
+				header = append(header, rs.SensorID)
+			}
+			values = append(values, readings...)
+		}
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		fmt.Fprintln(zw, strings.Join(header, ","))
+		row := strings.Join(values, ",")
+		for t := start; !t.After(end); t = t.Add(ecobee.RuntimeInterval) {
+			fmt.Fprintf(zw, "%s,%s\n", t.In(th.loc).Format("2006-01-02,15:04:05"), row)
+		}
+		if err := zw.Close(); err != nil {
+			writeError(w, err)
+			return
+		}
+		name := "/files/" + id + "/" + th.t.Identifier + ".csv.gz"
+		s.files[name] = b.Bytes()
+		j.files = append(j.files, name)
+	}
+	s.jobs[id] = j
+	writeJSON(w, http.StatusOK, ecobee.RuntimeReportJobResponse{JobID: id})
+}
+
+// handleReportJobStatus reports a job as processing for
+// Server.ReportJobPolls polls, and then as completed.
+func (s *Server) handleReportJobStatus(w http.ResponseWriter, r *http.Request) {
+	var req ecobee.ReportJobRequest
+	if err := json.Unmarshal([]byte(r.URL.Query().Get("json")), &req); err != nil {
+		writeError(w, errorf(codeSerialization, "invalid json: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[req.JobID]
+	if !ok {
+		writeError(w, errorf(codeValidation, "unknown job %q", req.JobID))
+		return
+	}
+	switch {
+	case j.job.Status == ecobee.JobCancelled:
+	case j.polls > 0:
+		j.polls--
+		j.job.Status = ecobee.JobProcessing
+	default:
+		j.job.Status = ecobee.JobCompleted
+		j.job.Files = nil
+		for _, f := range j.files {
+			j.job.Files = append(j.job.Files, s.URL+f)
+		}
+	}
+	writeJSON(w, http.StatusOK, ecobee.ReportJobResponse{Jobs: []ecobee.ReportJob{j.job}})
+}
+
+func (s *Server) handleReportJobCancel(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req ecobee.ReportJobRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, errorf(codeSerialization, "invalid json: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[req.JobID]
+	if !ok {
+		writeError(w, errorf(codeValidation, "unknown job %q", req.JobID))
+		return
+	}
+	if j.job.Status != ecobee.JobCompleted {
+		j.job.Status = ecobee.JobCancelled
+	}
+	writeJSON(w, http.StatusOK, ecobee.ReportJobResponse{Jobs: []ecobee.ReportJob{j.job}})
+}
+
+// handleFile serves report files.  Like ecobee's file URLs, they need
+// no access token.
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	b, ok := s.files[r.URL.Path]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Write(b)
+}
@@ -0,0 +1,1226 @@
+// Package ecobeetest provides an in-process fake of the ecobee API for
+// use in tests.
+package ecobeetest
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+// ecobee status codes returned by the fake.
+const (
+	codeSuccess          = 0
+	codeAuthFailed       = 1
+	codeProcessingError  = 3
+	codeSerialization    = 4
+	codeValidation       = 7
+	codeInvalidFunction  = 8
+	codeInvalidSelection = 9
+	codeTokenExpired     = 14
+	codeDuplicateData    = 15
+)
+
+// apiError is a failure reported to the client with an ecobee status
+// code.
+type apiError struct {
+	code    int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func errorf(code int, format string, args ...interface{}) error {
+	return &apiError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// DefaultPageSize is the number of thermostats returned per page when
+// Server.PageSize is not set.
+const DefaultPageSize = 25
+
+// Server is a fake ecobee API backed by an in-memory thermostat model.
+// Use its URL with ecobee.WithBaseURL.
+type Server struct {
+	*httptest.Server
+
+	// PageSize limits the number of thermostats returned per page.
+	PageSize int
+
+	// ReportJobPolls is the number of status requests for which a
+	// runtime report job stays processing before it completes.
+	ReportJobPolls int
+
+	mu          sync.Mutex
+	thermostats []*thermostat
+	rev         int
+
+	codes         map[string]bool
+	accessTokens  map[string]time.Time
+	refreshTokens map[string]bool
+	tokenCount    int
+
+	failures int
+
+	reportRequests []ecobee.RuntimeReportRequest
+
+	groups   []ecobee.Group
+	groupSeq int
+
+	sets   map[string]*hierarchySet
+	setSeq int
+	users  []hierarchyUser
+
+	jobs   map[string]*reportJob
+	jobSeq int
+	files  map[string][]byte
+}
+
+type thermostat struct {
+	t         ecobee.Thermostat
+	loc       *time.Location
+	equipment []string
+	messages  []string
+	meters    map[string]float64
+
+	alertsRev, intervalRev string
+}
+
+// NewServer starts a fake ecobee API server.  Callers should Close it
+// when done.
+func NewServer() *Server {
+	s := &Server{
+		codes:         map[string]bool{},
+		accessTokens:  map[string]time.Time{},
+		refreshTokens: map[string]bool{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", s.handleAuthorize)
+	mux.HandleFunc("/token", s.handleToken)
+	mux.HandleFunc("/1/thermostat", s.authenticated(s.handleThermostat))
+	mux.HandleFunc("/1/thermostatSummary", s.authenticated(s.handleThermostatSummary))
+	mux.HandleFunc("/1/runtimeReport", s.authenticated(s.handleRuntimeReport))
+	mux.HandleFunc("/1/meterReport", s.authenticated(s.handleMeterReport))
+	mux.HandleFunc("/1/group", s.authenticated(s.handleGroup))
+	mux.HandleFunc("/1/hierarchy/set", s.authenticated(s.handleHierarchySet))
+	mux.HandleFunc("/1/hierarchy/thermostat", s.authenticated(s.handleHierarchyThermostat))
+	mux.HandleFunc("/1/hierarchy/user", s.authenticated(s.handleHierarchyUser))
+	mux.HandleFunc("/1/runtimeReportJob/create", s.authenticated(s.handleReportJobCreate))
+	mux.HandleFunc("/1/runtimeReportJob/status", s.authenticated(s.handleReportJobStatus))
+	mux.HandleFunc("/1/runtimeReportJob/cancel", s.authenticated(s.handleReportJobCancel))
+	mux.HandleFunc("/files/", s.handleFile)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// NewClient authorizes against the fake and returns a Client using it.
+// The token is cached in cacheFile.
+func (s *Server) NewClient(cacheFile string, opts ...ecobee.ClientOption) (*ecobee.Client, error) {
+	opts = append([]ecobee.ClientOption{ecobee.WithBaseURL(s.URL)}, opts...)
+	pr, err := ecobee.Authorize("ecobeetest", opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := ecobee.SaveToken("ecobeetest", cacheFile, pr.Code, opts...); err != nil {
+		return nil, err
+	}
+	return ecobee.NewClientWithOptions("ecobeetest", cacheFile, opts...), nil
+}
+
+// AddThermostat adds t to the model.  Runtime and revision fields left
+// empty are filled in from the current climate.  The thermostat's time
+// zone is taken from its Location or ThermostatTime and UtcTime, and
+// defaults to UTC.
+func (s *Server) AddThermostat(t ecobee.Thermostat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	th := &thermostat{t: copyThermostat(t), loc: time.UTC}
+	if loc, err := t.TimeZone(); err == nil {
+		th.loc = loc
+	}
+	th.t.IsRegistered = true
+	th.t.Runtime.Connected = true
+	if th.t.Runtime.DesiredHeat == 0 && th.t.Runtime.DesiredCool == 0 {
+		if c := th.t.Program.Climate(th.t.Program.CurrentClimateRef); c != nil {
+			th.t.Runtime.DesiredHeat = c.HeatTemp
+			th.t.Runtime.DesiredCool = c.CoolTemp
+		}
+	}
+	s.touch(th)
+	th.alertsRev = th.t.ThermostatRev
+	th.intervalRev = th.t.ThermostatRev
+	s.thermostats = append(s.thermostats, th)
+}
+
+// Thermostat returns a copy of the modelled thermostat with identifier id.
+func (s *Server) Thermostat(id string) (ecobee.Thermostat, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	th := s.find(id)
+	if th == nil {
+		return ecobee.Thermostat{}, false
+	}
+	return copyThermostat(th.t), true
+}
+
+// SetEquipmentStatus sets the equipment reported as running by the
+// thermostat summary, e.g. "fan", "compCool1".
+func (s *Server) SetEquipmentStatus(id string, running ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if th := s.find(id); th != nil {
+		th.equipment = append([]string(nil), running...)
+		th.t.Runtime.RuntimeRev = s.nextRev()
+	}
+}
+
+// Messages returns the messages sent to thermostat id with sendMessage.
+func (s *Server) Messages(id string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if th := s.find(id); th != nil {
+		return append([]string(nil), th.messages...)
+	}
+	return nil
+}
+
+// AddAlert raises alert a on thermostat id.  An empty AcknowledgeRef
+// is filled in.
+func (s *Server) AddAlert(id string, a ecobee.Alert) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if th := s.find(id); th != nil {
+		if a.AcknowledgeRef == "" {
+			a.AcknowledgeRef = s.nextRev()
+		}
+		a.ThermostatIdentifier = id
+		th.t.Alerts = append(th.t.Alerts, a)
+		th.alertsRev = s.nextRev()
+	}
+}
+
+// ExpireTokens expires every access token issued so far, as if ecobee
+// had revoked them.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for tok := range s.accessTokens {
+		s.accessTokens[tok] = time.Time{}
+	}
+}
+
+// FailRequests makes the next n API requests fail with HTTP 503, as
+// during an ecobee outage.
+func (s *Server) FailRequests(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+func (s *Server) find(id string) *thermostat {
+	for _, th := range s.thermostats {
+		if th.t.Identifier == id {
+			return th
+		}
+	}
+	return nil
+}
+
+// nextRev returns a new, increasing revision string.
+func (s *Server) nextRev() string {
+	s.rev++
+	return fmt.Sprintf("%012d", s.rev)
+}
+
+// touch marks th as modified.
+func (s *Server) touch(th *thermostat) {
+	rev := s.nextRev()
+	th.t.ThermostatRev = rev
+	th.t.Runtime.RuntimeRev = rev
+	th.t.LastModified = time.Now().UTC().Format("2006-01-02 15:04:05")
+}
+
+// match returns the thermostats selected by sel.
+func (s *Server) match(sel ecobee.Selection) ([]*thermostat, error) {
+	switch sel.SelectionType {
+	case "registered":
+		return s.thermostats, nil
+	case "thermostats":
+		var ths []*thermostat
+		for _, id := range strings.Split(sel.SelectionMatch, ",") {
+			if th := s.find(id); th != nil {
+				ths = append(ths, th)
+			}
+		}
+		return ths, nil
+	case "managementSet":
+		var ths []*thermostat
+		for _, th := range s.thermostats {
+			if inSet(s.setOf(th.t.Identifier), sel.SelectionMatch) {
+				ths = append(ths, th)
+			}
+		}
+		return ths, nil
+	}
+	return nil, errorf(codeInvalidSelection, "unsupported selectionType %q", sel.SelectionType)
+}
+
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "ecobeePin" || q.Get("client_id") == "" {
+		writeOAuthError(w, "invalid_request")
+		return
+	}
+	s.mu.Lock()
+	s.tokenCount++
+	n := s.tokenCount
+	code := fmt.Sprintf("code-%d", n)
+	// PINs are considered authorized by the user immediately.
+	s.codes[code] = true
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, ecobee.PinResponse{
+		EcobeePin: fmt.Sprintf("PIN%d", n),
+		Code:      code,
+	})
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch q.Get("grant_type") {
+	case "ecobeePin":
+		if !s.codes[q.Get("code")] {
+			writeOAuthError(w, "invalid_grant")
+			return
+		}
+		delete(s.codes, q.Get("code"))
+	case "refresh_token":
+		if !s.refreshTokens[q.Get("refresh_token")] {
+			writeOAuthError(w, "invalid_grant")
+			return
+		}
+		delete(s.refreshTokens, q.Get("refresh_token"))
+	default:
+		writeOAuthError(w, "unsupported_grant_type")
+		return
+	}
+	s.tokenCount++
+	access := fmt.Sprintf("access-%d", s.tokenCount)
+	refresh := fmt.Sprintf("refresh-%d", s.tokenCount)
+	s.accessTokens[access] = time.Now().Add(time.Hour)
+	s.refreshTokens[refresh] = true
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  access,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": refresh,
+		"scope":         "smartWrite",
+	})
+}
+
+// authenticated rejects requests without a valid access token the way
+// ecobee does.  It also injects the failures requested by FailRequests.
+func (s *Server) authenticated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tok := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		exp, ok := s.accessTokens[tok]
+		fail := s.failures > 0
+		if fail {
+			s.failures--
+		}
+		s.mu.Unlock()
+		switch {
+		case fail:
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		case !ok:
+			writeStatus(w, http.StatusUnauthorized, codeAuthFailed, "Authentication failed.")
+		case time.Now().After(exp):
+			writeStatus(w, http.StatusInternalServerError, codeTokenExpired,
+				"Authentication token has expired. Refresh your tokens. Please refer to /authorize.")
+		default:
+			h(w, r)
+		}
+	}
+}
+
+func (s *Server) handleThermostat(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.getThermostats(w, r)
+	case http.MethodPost:
+		s.updateThermostats(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) getThermostats(w http.ResponseWriter, r *http.Request) {
+	var req ecobee.GetThermostatsRequest
+	if err := json.Unmarshal([]byte(r.URL.Query().Get("json")), &req); err != nil {
+		writeError(w, errorf(codeSerialization, "invalid json: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ths, err := s.match(req.Selection)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	size := s.PageSize
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	page := req.Page.Page
+	if page <= 0 {
+		page = 1
+	}
+	pages := (len(ths) + size - 1) / size
+	if pages == 0 {
+		pages = 1
+	}
+	start := min((page-1)*size, len(ths))
+	end := min(start+size, len(ths))
+
+	resp := ecobee.GetThermostatsResponse{
+		Page: ecobee.Page{
+			Page:       page,
+			TotalPages: pages,
+			PageSize:   size,
+			Total:      len(ths),
+		},
+		ThermostatList: []ecobee.Thermostat{},
+	}
+	now := time.Now()
+	for _, th := range ths[start:end] {
+		th.t.UtcTime = now.UTC().Format(ecobee.DateTimeLayout)
+		th.t.ThermostatTime = now.In(th.loc).Format(ecobee.DateTimeLayout)
+		resp.ThermostatList = append(resp.ThermostatList, selectSections(th.t, req.Selection))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// selectSections returns a copy of t with only the sections requested
+// by sel.
+func selectSections(t ecobee.Thermostat, sel ecobee.Selection) ecobee.Thermostat {
+	t = copyThermostat(t)
+	if !sel.IncludeSettings {
+		t.Settings = ecobee.Settings{}
+	}
+	if !sel.IncludeLocation {
+		t.Location = ecobee.Location{}
+	}
+	if !sel.IncludeRuntime {
+		t.Runtime = ecobee.Runtime{}
+	}
+	if !sel.IncludeExtendedRuntime {
+		t.ExtendedRuntime = ecobee.ExtendedRuntime{}
+	}
+	if !sel.IncludeEvents {
+		t.Events = nil
+	}
+	if !sel.IncludeProgram {
+		t.Program = ecobee.Program{}
+	}
+	if !sel.IncludeSensors {
+		t.RemoteSensors = nil
+	}
+	if !sel.IncludeWeather {
+		t.Weather = ecobee.Weather{}
+	}
+	if !sel.IncludeAlerts {
+		t.Alerts = nil
+	}
+	return t
+}
+
+// rawFunction is an ecobee.Function with undecoded parameters.
+type rawFunction struct {
+	Type   string          `json:"type"`
+	Params json.RawMessage `json:"params"`
+}
+
+// rawThermostatUpdate is the thermostat object of an update request,
+// with its sections undecoded.
+type rawThermostatUpdate struct {
+	Settings json.RawMessage `json:"settings"`
+	Program  json.RawMessage `json:"program"`
+}
+
+type rawUpdateRequest struct {
+	Selection  ecobee.Selection     `json:"selection"`
+	Thermostat *rawThermostatUpdate `json:"thermostat"`
+	Functions  []rawFunction        `json:"functions"`
+}
+
+func (s *Server) updateThermostats(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req rawUpdateRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, errorf(codeSerialization, "invalid json: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ths, err := s.match(req.Selection)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for _, th := range ths {
+		if req.Thermostat != nil {
+			if err := s.update(th, req.Thermostat); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+		for _, f := range req.Functions {
+			if err := s.apply(th, f); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+	}
+	writeStatus(w, http.StatusOK, codeSuccess, "")
+}
+
+// update writes the sections of u to th.  Like ecobee, only the fields
+// present in a section are changed.
+func (s *Server) update(th *thermostat, u *rawThermostatUpdate) error {
+	if u.Settings != nil {
+		if err := json.Unmarshal(u.Settings, &th.t.Settings); err != nil {
+			return errorf(codeSerialization, "invalid settings: %v", err)
+		}
+	}
+	if u.Program != nil {
+		// Unlike settings, the program is replaced as a whole.
+		var p ecobee.Program
+		if err := json.Unmarshal(u.Program, &p); err != nil {
+			return errorf(codeSerialization, "invalid program: %v", err)
+		}
+		if err := checkProgram(&p); err != nil {
+			return errorf(codeValidation, "invalid program: %v", err)
+		}
+		// Like ecobee, give new climates refs.
+		for i := range p.Climates {
+			for n := 1; p.Climates[i].ClimateRef == ""; n++ {
+				if ref := fmt.Sprintf("smart%d", n); p.Climate(ref) == nil {
+					p.Climates[i].ClimateRef = ref
+				}
+			}
+		}
+		p.CurrentClimateRef = th.t.Program.CurrentClimateRef
+		th.t.Program = p
+	}
+	s.touch(th)
+	return nil
+}
+
+// checkProgram checks p the way ecobee does: the schedule has 48 half
+// hour slots for each of the 7 days, and every slot names one of the
+// climates.  New climates, without a ref, need a name.  The read only
+// currentClimateRef must not be set.
+func checkProgram(p *ecobee.Program) error {
+	if p.CurrentClimateRef != "" {
+		return fmt.Errorf("currentClimateRef is read only")
+	}
+	refs := map[string]bool{}
+	for _, c := range p.Climates {
+		switch {
+		case c.ClimateRef == "" && c.Name == "":
+			return fmt.Errorf("climate without ref or name")
+		case c.ClimateRef == "":
+		case refs[c.ClimateRef]:
+			return fmt.Errorf("climateRef %q used twice", c.ClimateRef)
+		default:
+			refs[c.ClimateRef] = true
+		}
+	}
+	if len(p.Schedule) != 7 {
+		return fmt.Errorf("schedule has %d days", len(p.Schedule))
+	}
+	for d, slots := range p.Schedule {
+		if len(slots) != 48 {
+			return fmt.Errorf("day %d has %d slots", d, len(slots))
+		}
+		for i, ref := range slots {
+			if !refs[ref] {
+				return fmt.Errorf("day %d slot %d has unknown climateRef %q", d, i, ref)
+			}
+		}
+	}
+	return nil
+}
+
+// apply runs function f against th.
+func (s *Server) apply(th *thermostat, f rawFunction) error {
+	switch f.Type {
+	case "setHold":
+		var p ecobee.SetHoldParams
+		if err := json.Unmarshal(f.Params, &p); err != nil {
+			return errorf(codeSerialization, "invalid setHold params: %v", err)
+		}
+		return s.setHold(th, p)
+	case "resumeProgram":
+		var p ecobee.ResumeProgramParams
+		if err := json.Unmarshal(f.Params, &p); err != nil {
+			return errorf(codeSerialization, "invalid resumeProgram params: %v", err)
+		}
+		s.resumeProgram(th, p)
+		return nil
+	case "sendMessage":
+		var p ecobee.SendMessageParams
+		if err := json.Unmarshal(f.Params, &p); err != nil {
+			return errorf(codeSerialization, "invalid sendMessage params: %v", err)
+		}
+		th.messages = append(th.messages, p.Text)
+		th.alertsRev = s.nextRev()
+		return nil
+	case "acknowledge":
+		var p ecobee.AcknowledgeParams
+		if err := json.Unmarshal(f.Params, &p); err != nil {
+			return errorf(codeSerialization, "invalid acknowledge params: %v", err)
+		}
+		return s.acknowledge(th, p)
+	case "createVacation":
+		var p ecobee.CreateVacationParams
+		if err := json.Unmarshal(f.Params, &p); err != nil {
+			return errorf(codeSerialization, "invalid createVacation params: %v", err)
+		}
+		return s.createVacation(th, p)
+	case "deleteVacation":
+		var p ecobee.DeleteVacationParams
+		if err := json.Unmarshal(f.Params, &p); err != nil {
+			return errorf(codeSerialization, "invalid deleteVacation params: %v", err)
+		}
+		return s.deleteVacation(th, p)
+	}
+	return errorf(codeInvalidFunction, "unsupported function %q", f.Type)
+}
+
+func (s *Server) setHold(th *thermostat, p ecobee.SetHoldParams) error {
+	now := time.Now()
+	ev := ecobee.Event{
+		Type:                  "hold",
+		Name:                  "auto",
+		Running:               true,
+		StartDate:             p.StartDate,
+		StartTime:             p.StartTime,
+		EndDate:               p.EndDate,
+		EndTime:               p.EndTime,
+		HeatHoldTemp:          p.HeatHoldTemp,
+		CoolHoldTemp:          p.CoolHoldTemp,
+		Fan:                   p.Fan,
+		IsTemperatureAbsolute: p.IsTemperatureAbsolute,
+		IsTemperatureRelative: p.IsTemperatureRelative,
+		HoldClimateRef:        p.HoldClimateRef,
+	}
+	if ev.StartDate == "" {
+		ev.StartDate, ev.StartTime = ecobee.FormatDateTime(now, th.loc)
+	}
+	if ev.Fan == "" {
+		ev.Fan = "auto"
+	}
+	start, err := ecobee.ParseDateTime(ev.StartDate, ev.StartTime, th.loc)
+	if err != nil {
+		return errorf(codeValidation, "invalid start: %v", err)
+	}
+	ev.Running = !start.After(now)
+	switch p.HoldType {
+	case "", "dateTime":
+		if ev.EndDate == "" {
+			return errorf(codeValidation, "dateTime hold needs endDate")
+		}
+	case "holdHours":
+		if p.HoldHours < 1 {
+			return errorf(codeValidation, "holdHours must be positive")
+		}
+		ev.EndDate, ev.EndTime = ecobee.FormatDateTime(start.Add(time.Duration(p.HoldHours)*time.Hour), th.loc)
+	case "nextTransition":
+		ev.EndDate, ev.EndTime = ecobee.FormatDateTime(nextTransition(&th.t.Program, start.In(th.loc)), th.loc)
+	case "indefinite":
+		ev.EndDate, ev.EndTime = indefiniteEndDate, indefiniteEndTime
+	default:
+		return errorf(codeValidation, "invalid holdType %q", p.HoldType)
+	}
+	if p.HoldClimateRef != "" {
+		c := th.t.Program.Climate(p.HoldClimateRef)
+		if c == nil {
+			return errorf(codeValidation, "unknown climate %q", p.HoldClimateRef)
+		}
+		ev.HeatHoldTemp = c.HeatTemp
+		ev.CoolHoldTemp = c.CoolTemp
+		ev.IsTemperatureAbsolute = true
+	}
+
+	// A new hold replaces any existing one.
+	events := []ecobee.Event{ev}
+	for _, e := range th.t.Events {
+		if e.Type != "hold" {
+			events = append(events, e)
+		}
+	}
+	th.t.Events = events
+
+	if !ev.Running {
+		s.touch(th)
+		return nil
+	}
+	if ev.IsTemperatureAbsolute {
+		th.t.Runtime.DesiredHeat = ev.HeatHoldTemp
+		th.t.Runtime.DesiredCool = ev.CoolHoldTemp
+	}
+	th.t.Runtime.DesiredFanMode = ev.Fan
+	s.touch(th)
+	return nil
+}
+
+// The end reported by ecobee for indefinite holds.
+const (
+	indefiniteEndDate = "2035-01-01"
+	indefiniteEndTime = "00:00:00"
+)
+
+// nextTransition returns the start of the first schedule slot after t
+// with a different climate than t's slot.  The schedule has 48 half
+// hour slots per day, starting on Monday.
+func nextTransition(p *ecobee.Program, t time.Time) time.Time {
+	slot := func(t time.Time) string {
+		day := (int(t.Weekday()) + 6) % 7
+		i := t.Hour()*2 + t.Minute()/30
+		if day >= len(p.Schedule) || i >= len(p.Schedule[day]) {
+			return ""
+		}
+		return p.Schedule[day][i]
+	}
+	cur := slot(t)
+	next := t.Truncate(time.Minute).Add(-time.Duration(t.Minute()%30) * time.Minute)
+	for i := 0; i < 7*48; i++ {
+		next = next.Add(30 * time.Minute)
+		if slot(next) != cur {
+			return next
+		}
+	}
+	return next
+}
+
+// acknowledge responds to an alert.  Accepted and declined alerts are
+// removed; snoozed ones stay.
+func (s *Server) acknowledge(th *thermostat, p ecobee.AcknowledgeParams) error {
+	if p.ThermostatIdentifier != th.t.Identifier {
+		return errorf(codeValidation, "thermostatIdentifier %q does not match %q", p.ThermostatIdentifier, th.t.Identifier)
+	}
+	for i, a := range th.t.Alerts {
+		if a.AcknowledgeRef != p.AckRef {
+			continue
+		}
+		switch p.AckType {
+		case ecobee.AckAccept, ecobee.AckDecline:
+			th.t.Alerts = append(th.t.Alerts[:i], th.t.Alerts[i+1:]...)
+		case ecobee.AckSnooze:
+			th.t.Alerts[i].Acknowledgement = string(p.AckType)
+			th.t.Alerts[i].RemindMeLater = p.RemindMeLater
+		default:
+			return errorf(codeValidation, "invalid ackType %q", p.AckType)
+		}
+		th.alertsRev = s.nextRev()
+		return nil
+	}
+	return errorf(codeValidation, "no alert with ackRef %q", p.AckRef)
+}
+
+// createVacation adds a vacation event.  Vacations are not run, they
+// are only listed.
+func (s *Server) createVacation(th *thermostat, p ecobee.CreateVacationParams) error {
+	if p.Name == "" {
+		return errorf(codeValidation, "vacation name is required")
+	}
+	for _, e := range th.t.Events {
+		if e.Type == "vacation" && e.Name == p.Name {
+			return errorf(codeDuplicateData, "vacation %q already exists", p.Name)
+		}
+	}
+	ev := ecobee.Event{
+		Type:                  "vacation",
+		Name:                  p.Name,
+		StartDate:             p.StartDate,
+		StartTime:             p.StartTime,
+		EndDate:               p.EndDate,
+		EndTime:               p.EndTime,
+		HeatHoldTemp:          p.HeatHoldTemp,
+		CoolHoldTemp:          p.CoolHoldTemp,
+		Fan:                   p.Fan,
+		FanMinOnTime:          p.FanMinOnTime,
+		IsTemperatureAbsolute: true,
+	}
+	if ev.StartDate == "" {
+		ev.StartDate, ev.StartTime = ecobee.FormatDateTime(time.Now(), th.loc)
+	}
+	if ev.Fan == "" {
+		ev.Fan = "auto"
+	}
+	start, err := ecobee.ParseDateTime(ev.StartDate, ev.StartTime, th.loc)
+	if err != nil {
+		return errorf(codeValidation, "invalid start: %v", err)
+	}
+	end, err := ecobee.ParseDateTime(ev.EndDate, ev.EndTime, th.loc)
+	if err != nil {
+		return errorf(codeValidation, "invalid end: %v", err)
+	}
+	if !end.After(start) {
+		return errorf(codeValidation, "vacation ends before it starts")
+	}
+	th.t.Events = append(th.t.Events, ev)
+	s.touch(th)
+	return nil
+}
+
+func (s *Server) deleteVacation(th *thermostat, p ecobee.DeleteVacationParams) error {
+	for i, e := range th.t.Events {
+		if e.Type == "vacation" && e.Name == p.Name {
+			th.t.Events = append(th.t.Events[:i], th.t.Events[i+1:]...)
+			s.touch(th)
+			return nil
+		}
+	}
+	return errorf(codeValidation, "no vacation named %q", p.Name)
+}
+
+func (s *Server) resumeProgram(th *thermostat, p ecobee.ResumeProgramParams) {
+	var events []ecobee.Event
+	resumed := false
+	for _, e := range th.t.Events {
+		if e.Type == "hold" && (p.ResumeAll || !resumed) {
+			resumed = true
+			continue
+		}
+		events = append(events, e)
+	}
+	th.t.Events = events
+
+	if c := th.t.Program.Climate(th.t.Program.CurrentClimateRef); c != nil {
+		th.t.Runtime.DesiredHeat = c.HeatTemp
+		th.t.Runtime.DesiredCool = c.CoolTemp
+	}
+	th.t.Runtime.DesiredFanMode = "auto"
+	s.touch(th)
+}
+
+func (s *Server) handleThermostatSummary(w http.ResponseWriter, r *http.Request) {
+	var req ecobee.GetThermostatSummaryRequest
+	if err := json.Unmarshal([]byte(r.URL.Query().Get("json")), &req); err != nil {
+		writeError(w, errorf(codeSerialization, "invalid json: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ths, err := s.match(req.Selection)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := ecobee.GetThermostatSummaryResponse{
+		ThermostatCount: len(ths),
+		RevisionList:    []string{},
+	}
+	for _, th := range ths {
+		t := th.t
+		resp.RevisionList = append(resp.RevisionList, strings.Join([]string{
+			t.Identifier, t.Name, fmt.Sprint(t.Runtime.Connected),
+			t.ThermostatRev, th.alertsRev, t.Runtime.RuntimeRev, th.intervalRev,
+		}, ":"))
+		if req.Selection.IncludeEquipmentStatus {
+			resp.StatusList = append(resp.StatusList, t.Identifier+":"+strings.Join(th.equipment, ","))
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RuntimeReportRequests returns the runtime report requests received
+// so far.
+func (s *Server) RuntimeReportRequests() []ecobee.RuntimeReportRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ecobee.RuntimeReportRequest(nil), s.reportRequests...)
+}
+
+// reportEquipment maps runtime report columns to the equipment names
+// of SetEquipmentStatus.
+var reportEquipment = map[string]string{
+	"auxHeat1":     "auxHeat1",
+	"auxHeat2":     "auxHeat2",
+	"auxHeat3":     "auxHeat3",
+	"compCool1":    "compCool1",
+	"compCool2":    "compCool2",
+	"compHeat1":    "heatPump",
+	"compHeat2":    "heatPump2",
+	"dehumidifier": "dehumidifier",
+	"economizer":   "economizer",
+	"fan":          "fan",
+	"humidifier":   "humidifier",
+	"ventilator":   "ventilator",
+}
+
+// handleRuntimeReport reports the thermostat's current runtime state
+// for every interval requested.
+func (s *Server) handleRuntimeReport(w http.ResponseWriter, r *http.Request) {
+	var req ecobee.RuntimeReportRequest
+	if err := json.Unmarshal([]byte(r.URL.Query().Get("body")), &req); err != nil {
+		writeError(w, errorf(codeSerialization, "invalid json: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reportRequests = append(s.reportRequests, req)
+	ths, err := s.match(req.Selection)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(ths) > 25 {
+		writeError(w, errorf(codeValidation, "too many thermostats: %d", len(ths)))
+		return
+	}
+	start, end, err := reportRange(req.StartDate, req.StartInterval, req.EndDate, req.EndInterval)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	columns := strings.Split(req.Columns, ",")
+
+	resp := ecobee.RuntimeReportResponse{
+		StartDate:     req.StartDate,
+		StartInterval: req.StartInterval,
+		EndDate:       req.EndDate,
+		EndInterval:   req.EndInterval,
+		Columns:       req.Columns,
+		ReportList:    []ecobee.RuntimeReportRows{},
+	}
+	for _, th := range ths {
+		values := runtimeValues(th, columns)
+
+		rows := ecobee.RuntimeReportRows{ThermostatIdentifier: th.t.Identifier}
+		sensors := ecobee.RuntimeSensorRows{
+			ThermostatIdentifier: th.t.Identifier,
+			Columns:              []string{"date", "time"},
+		}
+		var readings []string
+		sensors.Sensors, readings = sensorReadings(th)
+		for _, rs := range sensors.Sensors {
+			sensors.Columns = append(sensors.Columns, rs.SensorID)
+		}
+		for t := start; !t.After(end); t = t.Add(ecobee.RuntimeInterval) {
+			when := t.In(th.loc).Format("2006-01-02,15:04:05")
+			rows.RowList = append(rows.RowList, strings.Join(append([]string{when}, values...), ","))
+			sensors.Data = append(sensors.Data, strings.Join(append([]string{when}, readings...), ","))
+		}
+		rows.RowCount = len(rows.RowList)
+		resp.ReportList = append(resp.ReportList, rows)
+		if req.IncludeSensors {
+			resp.SensorList = append(resp.SensorList, sensors)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SetMeter makes thermostat id report value for every interval of
+// meterType, e.g. ecobee.MeterEnergy, in meter reports.
+func (s *Server) SetMeter(id, meterType string, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if th := s.find(id); th != nil {
+		if th.meters == nil {
+			th.meters = map[string]float64{}
+		}
+		th.meters[meterType] = value
+	}
+}
+
+func (s *Server) handleMeterReport(w http.ResponseWriter, r *http.Request) {
+	var req ecobee.MeterReportRequest
+	if err := json.Unmarshal([]byte(r.URL.Query().Get("body")), &req); err != nil {
+		writeError(w, errorf(codeSerialization, "invalid json: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ths, err := s.match(req.Selection)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(ths) > 25 {
+		writeError(w, errorf(codeValidation, "too many thermostats: %d", len(ths)))
+		return
+	}
+	start, end, err := reportRange(req.StartDate, req.StartInterval, req.EndDate, req.EndInterval)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := ecobee.MeterReportResponse{ReportList: []ecobee.MeterReport{}}
+	for _, th := range ths {
+		mr := ecobee.MeterReport{ThermostatIdentifier: th.t.Identifier, MeterList: []ecobee.MeterReportData{}}
+		for _, m := range strings.Split(req.Meters, ",") {
+			v, ok := th.meters[m]
+			if !ok {
+				continue
+			}
+			d := ecobee.MeterReportData{MeterType: m}
+			for t := start; !t.After(end); t = t.Add(ecobee.RuntimeInterval) {
+				d.Data = append(d.Data, t.In(th.loc).Format("2006-01-02,15:04:05,")+fmt.Sprint(v))
+			}
+			mr.MeterList = append(mr.MeterList, d)
+		}
+		resp.ReportList = append(resp.ReportList, mr)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// sensorReadings returns the sensors of th as reported by the runtime
+// report, one for each capability, and their current readings.
+func sensorReadings(th *thermostat) ([]ecobee.RuntimeSensor, []string) {
+	var sensors []ecobee.RuntimeSensor
+	var readings []string
+	for _, rs := range th.t.RemoteSensors {
+		for _, c := range rs.Capability {
+			sensors = append(sensors, ecobee.RuntimeSensor{
+				SensorID: rs.ID + ":" + c.ID, SensorName: rs.Name, SensorType: c.Type, SensorUsage: "monitor",
+			})
+			v := c.Value
+			if t, err := c.Temperature(); err == nil {
+				v = t.Format(ecobee.Fahrenheit)
+			}
+			readings = append(readings, v)
+		}
+	}
+	return sensors, readings
+}
+
+// runtimeValues returns th's current runtime state as runtime report
+// values for columns.  Columns it doesn't model are empty.
+func runtimeValues(th *thermostat, columns []string) []string {
+	running := map[string]bool{}
+	for _, e := range th.equipment {
+		running[e] = true
+	}
+	var values []string
+	for _, c := range columns {
+		var v string
+		switch c {
+		case "hvacMode":
+			v = string(th.t.Settings.HvacMode)
+		case "zoneClimate":
+			v = th.t.Program.CurrentClimateRef
+		case "zoneAveTemp":
+			v = th.t.Runtime.ActualTemperature.Format(ecobee.Fahrenheit)
+		case "zoneHeatTemp":
+			v = th.t.Runtime.DesiredHeat.Format(ecobee.Fahrenheit)
+		case "zoneCoolTemp":
+			v = th.t.Runtime.DesiredCool.Format(ecobee.Fahrenheit)
+		case "zoneHumidity":
+			v = fmt.Sprint(th.t.Runtime.ActualHumidity)
+		default:
+			if e, ok := reportEquipment[c]; ok {
+				v = "0"
+				if running[e] {
+					v = "300"
+				}
+			}
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// reportRange returns the first and last UTC intervals of a report
+// request, which may span at most 31 days.
+func reportRange(startDate string, startInterval int, endDate string, endInterval int) (time.Time, time.Time, error) {
+	start, err := reportTime(startDate, startInterval)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := reportTime(endDate, endInterval)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end.Before(start) || end.Sub(start) > 31*24*time.Hour {
+		return time.Time{}, time.Time{}, errorf(codeValidation, "invalid report range %s to %s", start, end)
+	}
+	return start, end, nil
+}
+
+// reportTime returns the start of a UTC report interval.
+func reportTime(date string, interval int) (time.Time, error) {
+	t, err := time.Parse(ecobee.DateLayout, date)
+	if err != nil || interval < 0 || interval > 287 {
+		return time.Time{}, errorf(codeValidation, "invalid report date %q interval %d", date, interval)
+	}
+	return t.Add(time.Duration(interval) * ecobee.RuntimeInterval), nil
+}
+
+func (s *Server) handleGroup(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var req ecobee.GetGroupsRequest
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("json")), &req); err != nil {
+			writeError(w, errorf(codeSerialization, "invalid json: %v", err))
+			return
+		}
+		if req.Selection.SelectionType != "registered" {
+			writeError(w, errorf(codeInvalidSelection, "groups require a registered selection"))
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		writeJSON(w, http.StatusOK, ecobee.GroupsResponse{Groups: s.copyGroups()})
+	case http.MethodPost:
+		s.updateGroups(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// updateGroups replaces all groups with those in the request.
+func (s *Server) updateGroups(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req ecobee.UpdateGroupsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, errorf(codeSerialization, "invalid json: %v", err))
+		return
+	}
+	if req.Selection.SelectionType != "registered" {
+		writeError(w, errorf(codeInvalidSelection, "groups require a registered selection"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	known := map[string]bool{}
+	for _, g := range s.groups {
+		known[g.GroupRef] = true
+	}
+	groups := []ecobee.Group{}
+	for _, g := range req.Groups {
+		if g.GroupName == "" {
+			writeError(w, errorf(codeValidation, "group name is required"))
+			return
+		}
+		if g.GroupRef != "" && !known[g.GroupRef] {
+			writeError(w, errorf(codeValidation, "unknown groupRef %q", g.GroupRef))
+			return
+		}
+		for _, id := range g.Thermostats {
+			if s.find(id) == nil {
+				writeError(w, errorf(codeValidation, "unknown thermostat %q in group %s", id, g.GroupName))
+				return
+			}
+		}
+		if g.GroupRef == "" {
+			s.groupSeq++
+			g.GroupRef = fmt.Sprintf("group%d", s.groupSeq)
+		}
+		if g.Thermostats == nil {
+			g.Thermostats = []string{}
+		}
+		groups = append(groups, g)
+	}
+	s.groups = groups
+	writeJSON(w, http.StatusOK, ecobee.GroupsResponse{Groups: s.copyGroups()})
+}
+
+// copyGroups returns a copy of the groups.
+func (s *Server) copyGroups() []ecobee.Group {
+	gs := []ecobee.Group{}
+	for _, g := range s.groups {
+		g.Thermostats = append([]string{}, g.Thermostats...)
+		gs = append(gs, g)
+	}
+	return gs
+}
+
+// copyThermostat returns a deep copy of t.
+func copyThermostat(t ecobee.Thermostat) ecobee.Thermostat {
+	j, err := json.Marshal(t)
+	if err != nil {
+		panic(fmt.Sprintf("ecobeetest: marshaling thermostat: %v", err))
+	}
+	var c ecobee.Thermostat
+	if err := json.Unmarshal(j, &c); err != nil {
+		panic(fmt.Sprintf("ecobeetest: unmarshaling thermostat: %v", err))
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeStatus(w http.ResponseWriter, httpStatus, code int, message string) {
+	writeJSON(w, httpStatus, map[string]ecobee.Status{
+		"status": {Code: code, Message: message},
+	})
+}
+
+// writeError reports err with its ecobee status code, or as a
+// processing error if it has none.
+func writeError(w http.ResponseWriter, err error) {
+	code := codeProcessingError
+	if e, ok := err.(*apiError); ok {
+		code = e.code
+	}
+	writeStatus(w, http.StatusInternalServerError, code, err.Error())
+}
+
+func writeOAuthError(w http.ResponseWriter, e string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error":             e,
+		"error_description": e,
+	})
+}
@@ -0,0 +1,149 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when the ecobee API rejects a request, either
+// with a non-200 HTTP response or a non-zero status code in the body.
+//
+// Use errors.Is with the Err* values below to test for a particular
+// ecobee status code, or errors.As to inspect the details.
+type APIError struct {
+	// HTTPStatus is the HTTP status code of the response.
+	HTTPStatus int
+	// Code is the ecobee status code, or 0 if the response did not
+	// contain one.
+	Code int
+	// Message is the ecobee status message, or the HTTP status text
+	// if the response did not contain one.
+	Message string
+	// Endpoint is the URL of the request, without the query string.
+	Endpoint string
+}
+
+// Sentinel errors for the ecobee status codes.  An *APIError matches
+// a sentinel under errors.Is when their Codes are equal.
+// https://www.ecobee.com/home/developer/api/documentation/v1/general/statusCodes.shtml
+var (
+	ErrAuthenticationFailed = &APIError{Code: 1, Message: "authentication failed"}
+	ErrNotAuthorized        = &APIError{Code: 2, Message: "not authorized"}
+	ErrProcessing           = &APIError{Code: 3, Message: "processing error"}
+	ErrSerialization        = &APIError{Code: 4, Message: "serialization error"}
+	ErrInvalidRequestFormat = &APIError{Code: 5, Message: "invalid request format"}
+	ErrTooManyThermostats   = &APIError{Code: 6, Message: "too many thermostats in selection match criteria"}
+	ErrValidation           = &APIError{Code: 7, Message: "validation error"}
+	ErrInvalidFunction      = &APIError{Code: 8, Message: "invalid function"}
+	ErrInvalidSelection     = &APIError{Code: 9, Message: "invalid selection"}
+	ErrInvalidPage          = &APIError{Code: 10, Message: "invalid page"}
+	ErrFunction             = &APIError{Code: 11, Message: "function error"}
+	ErrPostNotSupported     = &APIError{Code: 12, Message: "post not supported for request"}
+	ErrGetNotSupported      = &APIError{Code: 13, Message: "get not supported for request"}
+	ErrTokenExpired         = &APIError{Code: 14, Message: "authentication token has expired"}
+	ErrDuplicateData        = &APIError{Code: 15, Message: "duplicate data violation"}
+	ErrTokenDeauthorized    = &APIError{Code: 16, Message: "token has been deauthorized by user"}
+)
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	b.WriteString("ecobee API error")
+	if e.Code != 0 {
+		fmt.Fprintf(&b, " %d", e.Code)
+	}
+	if e.HTTPStatus != 0 {
+		fmt.Fprintf(&b, " (HTTP %d)", e.HTTPStatus)
+	}
+	if e.Endpoint != "" {
+		fmt.Fprintf(&b, " from %s", e.Endpoint)
+	}
+	fmt.Fprintf(&b, ": %s", e.Message)
+	return b.String()
+}
+
+// Is reports whether target is an *APIError with the same ecobee
+// status code.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.Code != 0 && t.Code == e.Code
+}
+
+// IsAuth reports whether the error means the credentials must be
+// refreshed or re-authorized.
+func (e *APIError) IsAuth() bool {
+	switch e.Code {
+	case ErrAuthenticationFailed.Code, ErrNotAuthorized.Code,
+		ErrTokenExpired.Code, ErrTokenDeauthorized.Code:
+		return true
+	}
+	return e.Code == 0 && (e.HTTPStatus == http.StatusUnauthorized || e.HTTPStatus == http.StatusForbidden)
+}
+
+// Temporary reports whether the request may succeed if retried
+// unchanged: server side processing errors and outages.
+func (e *APIError) Temporary() bool {
+	if e.Code == ErrProcessing.Code {
+		return true
+	}
+	return e.Code == 0 && e.HTTPStatus >= 500
+}
+
+// newAPIError builds an *APIError from an unsuccessful response body.
+// It understands both the API's status object and the OAuth error
+// format used by the token endpoint.
+func newAPIError(endpoint string, httpStatus int, body []byte) *APIError {
+	e := &APIError{
+		HTTPStatus: httpStatus,
+		Endpoint:   endpoint,
+		Message:    http.StatusText(httpStatus),
+	}
+	var r struct {
+		Status           Status `json:"status"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return e
+	}
+	switch {
+	case r.Status.Code != 0 || r.Status.Message != "":
+		e.Code = r.Status.Code
+		e.Message = r.Status.Message
+	case r.Error != "":
+		e.Message = r.Error
+		if r.ErrorDescription != "" && r.ErrorDescription != r.Error {
+			e.Message += ": " + r.ErrorDescription
+		}
+	}
+	return e
+}
+
+// statusError returns an *APIError if s reports a failure, nil
+// otherwise.
+func statusError(endpoint string, s Status) error {
+	if s.Code == 0 {
+		return nil
+	}
+	return &APIError{
+		HTTPStatus: http.StatusOK,
+		Code:       s.Code,
+		Message:    s.Message,
+		Endpoint:   endpoint,
+	}
+}
@@ -16,9 +16,12 @@ package ecobee
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -26,10 +29,45 @@ import (
 	"github.com/golang/glog"
 )
 
-const thermostatAPIURL = `https://api.ecobee.com/1/thermostat`
-const thermostatSummaryURL = `https://api.ecobee.com/1/thermostatSummary`
+const (
+	authorizePath         = "authorize"
+	tokenPath             = "token"
+	thermostatPath        = "1/thermostat"
+	thermostatSummaryPath = "1/thermostatSummary"
+	runtimeReportPath     = "1/runtimeReport"
+	meterReportPath       = "1/meterReport"
+	reportJobCreatePath   = "1/runtimeReportJob/create"
+	reportJobStatusPath   = "1/runtimeReportJob/status"
+	reportJobCancelPath   = "1/runtimeReportJob/cancel"
+	groupPath             = "1/group"
+
+	hierarchySetPath        = "1/hierarchy/set"
+	hierarchyThermostatPath = "1/hierarchy/thermostat"
+	hierarchyUserPath       = "1/hierarchy/user"
+)
+
+// endpointURL joins an API path onto baseURL.
+func endpointURL(baseURL, path string) string {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + path
+}
+
+func (c *Client) url(path string) string {
+	return endpointURL(c.baseURL, path)
+}
 
 func (c *Client) UpdateThermostat(utr UpdateThermostatRequest) error {
+	return c.UpdateThermostatContext(context.Background(), utr)
+}
+
+// UpdateThermostatContext is like UpdateThermostat, using ctx for the
+// request.
+func (c *Client) UpdateThermostatContext(ctx context.Context, utr UpdateThermostatRequest) error {
+	if err := utr.Selection.Validate(); err != nil {
+		return err
+	}
 	j, err := json.Marshal(&utr)
 	if err != nil {
 		return fmt.Errorf("error marshaling json: %v", err)
@@ -37,19 +75,9 @@ func (c *Client) UpdateThermostat(utr UpdateThermostatRequest) error {
 
 	glog.V(1).Infof("UpdateThermostat request: %s", j)
 
-	// everything below here can be factored out into a common POST func
-	resp, err := c.Post(thermostatAPIURL, "application/json", bytes.NewReader(j))
-	if err != nil {
-		return fmt.Errorf("error on post request: %v", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("invalid server response: %v", resp.Status)
-	}
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.post(ctx, c.url(thermostatPath), j, utr.idempotent())
 	if err != nil {
-		return fmt.Errorf("error reading body: %v", err)
+		return err
 	}
 
 	var s UpdateThermostatResponse
@@ -59,15 +87,35 @@ func (c *Client) UpdateThermostat(utr UpdateThermostatRequest) error {
 
 	glog.V(1).Infof("UpdateThermostat response: %+v", s)
 
-	if s.Status.Code == 0 {
-		return nil
+	return statusError(c.url(thermostatPath), s.Status)
+}
+
+// idempotentFunctions lists the thermostat functions that leave the
+// thermostat in the same state no matter how many times they are
+// applied.
+var idempotentFunctions = map[string]bool{
+	"setHold":     true,
+	"acknowledge": true,
+}
+
+// idempotent reports whether utr can be safely sent more than once.
+func (utr *UpdateThermostatRequest) idempotent() bool {
+	for _, f := range utr.Functions {
+		if !idempotentFunctions[f.Type] {
+			return false
+		}
 	}
-	return fmt.Errorf("API error: %s", s.Status.Message)
+	return true
 }
 
 func (c *Client) GetThermostat(thermostatID string, opts ...SelectionOption) (*Thermostat, error) {
+	return c.GetThermostatContext(context.Background(), thermostatID, opts...)
+}
+
+// GetThermostatContext is like GetThermostat, using ctx for the request.
+func (c *Client) GetThermostatContext(ctx context.Context, thermostatID string, opts ...SelectionOption) (*Thermostat, error) {
 	s := Selection{
-		SelectionType:  "thermostats",
+		SelectionType:  SelectionTypeThermostats,
 		SelectionMatch: thermostatID,
 
 		IncludeAlerts:          false,
@@ -80,11 +128,9 @@ func (c *Client) GetThermostat(thermostatID string, opts ...SelectionOption) (*T
 		IncludeWeather:         false,
 	}
 
-	for _, o := range opts {
-		o(s)
-	}
+	s.Apply(opts...)
 
-	thermostats, err := c.GetThermostats(s)
+	thermostats, err := c.GetThermostatsContext(ctx, s)
 	if err != nil {
 		return nil, err
 	} else if len(thermostats) != 1 {
@@ -94,33 +140,85 @@ func (c *Client) GetThermostat(thermostatID string, opts ...SelectionOption) (*T
 }
 
 func (c *Client) GetThermostats(selection Selection) ([]Thermostat, error) {
+	return c.GetThermostatsContext(context.Background(), selection)
+}
+
+// GetThermostatsContext is like GetThermostats, using ctx for the
+// request.
+func (c *Client) GetThermostatsContext(ctx context.Context, selection Selection) ([]Thermostat, error) {
+	var thermostats []Thermostat
+	err := c.ThermostatPages(ctx, selection, func(ts []Thermostat, _ Page) error {
+		thermostats = append(thermostats, ts...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return thermostats, nil
+}
+
+// ThermostatPages fetches the thermostats matching selection one page
+// at a time, calling fn with each page in turn.  It stops early if fn
+// returns an error, and returns that error.
+func (c *Client) ThermostatPages(ctx context.Context, selection Selection, fn func(ts []Thermostat, p Page) error) error {
+	for page := 1; ; page++ {
+		ts, p, err := c.GetThermostatsPage(ctx, selection, page)
+		if err != nil {
+			return err
+		}
+		if err := fn(ts, p); err != nil {
+			return err
+		}
+		if len(ts) == 0 || page >= p.TotalPages {
+			return nil
+		}
+	}
+}
+
+// GetThermostatsPage fetches a single page of the thermostats matching
+// selection.  Pages are numbered from 1.  The returned Page describes
+// the full result set.
+func (c *Client) GetThermostatsPage(ctx context.Context, selection Selection, page int) ([]Thermostat, Page, error) {
+	if err := selection.Validate(); err != nil {
+		return nil, Page{}, err
+	}
 	req := GetThermostatsRequest{
 		Selection: selection,
+		Page:      Page{Page: page},
 	}
 	j, err := json.Marshal(&req)
 	if err != nil {
-		return nil, fmt.Errorf("error marshaling json: %v", err)
+		return nil, Page{}, fmt.Errorf("error marshaling json: %v", err)
 	}
 
-	body, err := c.get(thermostatAPIURL, j)
+	body, err := c.get(ctx, c.url(thermostatPath), j)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching thermostats: %v", err)
+		return nil, Page{}, fmt.Errorf("error fetching thermostats: %w", err)
 	}
 
 	var r GetThermostatsResponse
 	if err = json.Unmarshal(body, &r); err != nil {
-		return nil, fmt.Errorf("error unmarshalling json: %v", err)
+		return nil, Page{}, fmt.Errorf("error unmarshalling json: %v", err)
 	}
 
 	glog.V(1).Infof("GetThermostats response: %#v", r)
 
-	if r.Status.Code != 0 {
-		return nil, fmt.Errorf("api error %d: %v", r.Status.Code, r.Status.Message)
+	if err := statusError(c.url(thermostatPath), r.Status); err != nil {
+		return nil, Page{}, err
 	}
-	return r.ThermostatList, nil
+	return r.ThermostatList, r.Page, nil
 }
 
 func (c *Client) GetThermostatSummary(selection Selection) (map[string]ThermostatSummary, error) {
+	return c.GetThermostatSummaryContext(context.Background(), selection)
+}
+
+// GetThermostatSummaryContext is like GetThermostatSummary, using ctx
+// for the request.
+func (c *Client) GetThermostatSummaryContext(ctx context.Context, selection Selection) (map[string]ThermostatSummary, error) {
+	if err := selection.Validate(); err != nil {
+		return nil, err
+	}
 	req := GetThermostatSummaryRequest{
 		Selection: selection,
 	}
@@ -129,9 +227,9 @@ func (c *Client) GetThermostatSummary(selection Selection) (map[string]Thermosta
 		return nil, fmt.Errorf("error marshaling json: %v", err)
 	}
 
-	body, err := c.get(thermostatSummaryURL, j)
+	body, err := c.get(ctx, c.url(thermostatSummaryPath), j)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching thermostat summary: %v", err)
+		return nil, fmt.Errorf("error fetching thermostat summary: %w", err)
 	}
 
 	var r GetThermostatSummaryResponse
@@ -141,6 +239,10 @@ func (c *Client) GetThermostatSummary(selection Selection) (map[string]Thermosta
 
 	glog.V(1).Infof("GetThermostatSummary response: %#v", r)
 
+	if err := statusError(c.url(thermostatSummaryPath), r.Status); err != nil {
+		return nil, err
+	}
+
 	var tsm = make(ThermostatSummaryMap, r.ThermostatCount)
 
 	for i := 0; i < r.ThermostatCount; i++ {
@@ -175,22 +277,97 @@ func (c *Client) GetThermostatSummary(selection Selection) (map[string]Thermosta
 	return tsm, nil
 }
 
-func (c *Client) get(endpoint string, rawRequest []byte) ([]byte, error) {
+func (c *Client) get(ctx context.Context, endpoint string, rawRequest []byte) ([]byte, error) {
 
 	glog.V(2).Infof("get(%s?json=%s)", endpoint, rawRequest)
-	request := url.QueryEscape(string(rawRequest))
-	resp, err := c.Get(fmt.Sprintf("%s?json=%s", endpoint, request))
+	return c.getQuery(ctx, endpoint, url.Values{"json": {string(rawRequest)}})
+}
+
+// getReport is like get for the report endpoints, which take their
+// request in the body parameter.
+func (c *Client) getReport(ctx context.Context, endpoint string, rawRequest []byte) ([]byte, error) {
+
+	glog.V(2).Infof("getReport(%s?body=%s)", endpoint, rawRequest)
+	return c.getQuery(ctx, endpoint, url.Values{"format": {"json"}, "body": {string(rawRequest)}})
+}
+
+func (c *Client) getQuery(ctx context.Context, endpoint string, q url.Values) ([]byte, error) {
+	return c.do(ctx, endpoint, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+q.Encode(), nil)
+	})
+}
+
+// post sends rawRequest to endpoint.  idempotent indicates whether the
+// request can safely be repeated if it fails.
+func (c *Client) post(ctx context.Context, endpoint string, rawRequest []byte, idempotent bool) ([]byte, error) {
+
+	glog.V(2).Infof("post(%s, %s)", endpoint, rawRequest)
+	return c.do(ctx, endpoint, idempotent, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(rawRequest))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+}
+
+// do sends the request built by newReq, retrying according to the
+// client's RetryPolicy, and returns the body of a successful response.
+// If ecobee reports that the access token has expired, the token is
+// refreshed and the request replayed once.
+func (c *Client) do(ctx context.Context, endpoint string, idempotent bool, newReq func() (*http.Request, error)) ([]byte, error) {
+	reauthorized := false
+	for attempt := 1; ; attempt++ {
+		// Concurrent requests rejected with the same token refresh
+		// it only once.
+		var sent string
+		if c.ts != nil {
+			sent = c.ts.currentAccessToken()
+		}
+		body, err := c.doOnce(ctx, endpoint, newReq)
+		if err != nil && !reauthorized && c.ts != nil && errors.Is(err, ErrTokenExpired) {
+			reauthorized = true
+			glog.V(1).Infof("access token rejected by %s, refreshing: %v", endpoint, err)
+			if rerr := c.ts.forceRefresh(ctx, sent); rerr != nil {
+				return nil, fmt.Errorf("error refreshing token: %v (after %w)", rerr, err)
+			}
+			// A rejected request had no effect, so replaying it is
+			// always safe.  It doesn't count as a retry.
+			attempt--
+			continue
+		}
+		if err == nil || attempt >= c.retry.MaxAttempts || !retryable(err, idempotent) {
+			return body, err
+		}
+		d := c.retry.backoff(attempt)
+		glog.V(1).Infof("retrying %s in %v after attempt %d: %v", endpoint, d, attempt, err)
+		if err := sleep(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) doOnce(ctx context.Context, endpoint string, newReq func() (*http.Request, error)) ([]byte, error) {
+	if err := c.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	req, err := newReq()
 	if err != nil {
-		return nil, fmt.Errorf("error on get request: %v", err)
+		return nil, fmt.Errorf("error creating request: %v", err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("invalid server response: %v", resp.Status)
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error on %s request: %w", strings.ToLower(req.Method), err)
 	}
+	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading body: %v", err)
+		return nil, fmt.Errorf("error reading body: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, newAPIError(endpoint, resp.StatusCode, body)
 	}
 
 	glog.V(2).Infof("responses: %s", body)
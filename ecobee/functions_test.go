@@ -0,0 +1,115 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/rspier/go-ecobee/ecobee/ecobeetest"
+)
+
+// newPagedServer starts a fake with n thermostats, 100 onwards, served
+// size to a page.
+func newPagedServer(t *testing.T, n, size int) (*ecobee.Client, []string) {
+	t.Helper()
+	s := ecobeetest.NewServer()
+	t.Cleanup(s.Close)
+	s.PageSize = size
+	var ids []string
+	for i := 0; i < n; i++ {
+		id := fmt.Sprint(100 + i)
+		s.AddThermostat(testThermostat(id, "Thermostat "+id))
+		ids = append(ids, id)
+	}
+	c, err := s.NewClient(filepath.Join(t.TempDir(), "authcache"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c, ids
+}
+
+func TestThermostatPages(t *testing.T) {
+	sel := ecobee.Selection{SelectionType: ecobee.SelectionTypeRegistered}
+	for _, tc := range []struct {
+		n, size   int
+		wantPages []int // thermostats on each page
+	}{
+		{0, 5, []int{0}},
+		{1, 1, []int{1}},
+		{3, 5, []int{3}},
+		{5, 5, []int{5}},
+		{6, 5, []int{5, 1}},
+		{10, 5, []int{5, 5}},
+		{11, 5, []int{5, 5, 1}},
+	} {
+		c, ids := newPagedServer(t, tc.n, tc.size)
+
+		var got []string
+		var pages []int
+		err := c.ThermostatPages(context.Background(), sel, func(ts []ecobee.Thermostat, p ecobee.Page) error {
+			pages = append(pages, len(ts))
+			if p.Page != len(pages) || p.TotalPages != len(tc.wantPages) || p.Total != tc.n {
+				t.Errorf("%d by %d: page %+v, want page %d of %d, %d in all", tc.n, tc.size, p, len(pages), len(tc.wantPages), tc.n)
+			}
+			for _, th := range ts {
+				got = append(got, th.Identifier)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%d by %d: ThermostatPages: %v", tc.n, tc.size, err)
+			continue
+		}
+		if !reflect.DeepEqual(pages, tc.wantPages) {
+			t.Errorf("%d by %d: pages = %v, want %v", tc.n, tc.size, pages, tc.wantPages)
+		}
+		if !reflect.DeepEqual(got, ids) {
+			t.Errorf("%d by %d: thermostats = %v, want %v", tc.n, tc.size, got, ids)
+		}
+
+		// A page past the end is empty.
+		ts, p, err := c.GetThermostatsPage(context.Background(), sel, len(tc.wantPages)+1)
+		if err != nil || len(ts) != 0 || p.Total != tc.n {
+			t.Errorf("%d by %d: page past the end = %d thermostats, %+v, %v; want none", tc.n, tc.size, len(ts), p, err)
+		}
+	}
+}
+
+func TestThermostatPagesStop(t *testing.T) {
+	c, _ := newPagedServer(t, 11, 5)
+	stop := errors.New("stop")
+
+	calls := 0
+	err := c.ThermostatPages(context.Background(), ecobee.Selection{SelectionType: ecobee.SelectionTypeRegistered},
+		func([]ecobee.Thermostat, ecobee.Page) error {
+			calls++
+			return stop
+		})
+	if err != stop || calls != 1 {
+		t.Errorf("ThermostatPages = %v after %d calls, want stop after 1", err, calls)
+	}
+
+	// GetThermostats collects every page.
+	ts, err := c.GetThermostats(ecobee.Selection{SelectionType: ecobee.SelectionTypeRegistered})
+	if err != nil || len(ts) != 11 {
+		t.Errorf("GetThermostats = %d thermostats, %v; want 11", len(ts), err)
+	}
+}
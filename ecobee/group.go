@@ -0,0 +1,224 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// Group is a group of thermostats whose settings ecobee keeps in sync.
+// Each Synchronize field selects what is synchronized.
+type Group struct {
+	// GroupRef identifies the group.  It is assigned by ecobee
+	// when the group is created.
+	GroupRef                   string   `json:"groupRef,omitempty"`
+	GroupName                  string   `json:"groupName"`
+	SynchronizeAlerts          bool     `json:"synchronizeAlerts"`
+	SynchronizeSystemMode      bool     `json:"synchronizeSystemMode"`
+	SynchronizeSchedule        bool     `json:"synchronizeSchedule"`
+	SynchronizeQuickSave       bool     `json:"synchronizeQuickSave"`
+	SynchronizeReminders       bool     `json:"synchronizeReminders"`
+	SynchronizeContractorInfo  bool     `json:"synchronizeContractorInfo"`
+	SynchronizeUserPreferences bool     `json:"synchronizeUserPreferences"`
+	SynchronizeUtilityInfo     bool     `json:"synchronizeUtilityInfo"`
+	SynchronizeLocation        bool     `json:"synchronizeLocation"`
+	SynchronizeReset           bool     `json:"synchronizeReset"`
+	SynchronizeVacation        bool     `json:"synchronizeVacation"`
+	Thermostats                []string `json:"thermostats"`
+}
+
+type GetGroupsRequest struct {
+	Selection Selection `json:"selection"`
+}
+
+// UpdateGroupsRequest replaces all of the account's groups with Groups.
+type UpdateGroupsRequest struct {
+	Selection Selection `json:"selection"`
+	Groups    []Group   `json:"groups"`
+}
+
+type GroupsResponse struct {
+	Groups []Group `json:"groups"`
+	Status Status  `json:"status"`
+}
+
+// syncFlags returns the Synchronize fields of g by name.
+func (g *Group) syncFlags() map[string]*bool {
+	return map[string]*bool{
+		"alerts":          &g.SynchronizeAlerts,
+		"systemMode":      &g.SynchronizeSystemMode,
+		"schedule":        &g.SynchronizeSchedule,
+		"quickSave":       &g.SynchronizeQuickSave,
+		"reminders":       &g.SynchronizeReminders,
+		"contractorInfo":  &g.SynchronizeContractorInfo,
+		"userPreferences": &g.SynchronizeUserPreferences,
+		"utilityInfo":     &g.SynchronizeUtilityInfo,
+		"location":        &g.SynchronizeLocation,
+		"reset":           &g.SynchronizeReset,
+		"vacation":        &g.SynchronizeVacation,
+	}
+}
+
+// GroupSyncNames returns the names accepted by Group.SetSync, e.g.
+// "schedule", sorted.
+func GroupSyncNames() []string {
+	var names []string
+	for n := range (&Group{}).syncFlags() {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetSync turns synchronization of name, one of GroupSyncNames, on or
+// off.  Names are matched ignoring case.
+func (g *Group) SetSync(name string, on bool) error {
+	for n, f := range g.syncFlags() {
+		if strings.EqualFold(n, name) {
+			*f = on
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown group synchronization %q, want one of %s", name, strings.Join(GroupSyncNames(), ", "))
+}
+
+// Syncs returns the names of what g synchronizes, sorted.
+func (g *Group) Syncs() []string {
+	var names []string
+	for n, f := range g.syncFlags() {
+		if *f {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasThermostat reports whether thermostat id is a member of g.
+func (g *Group) HasThermostat(id string) bool {
+	for _, t := range g.Thermostats {
+		if t == id {
+			return true
+		}
+	}
+	return false
+}
+
+// GetGroups fetches the thermostat groups of the account.
+func (c *Client) GetGroups() ([]Group, error) {
+	return c.GetGroupsContext(context.Background())
+}
+
+// GetGroupsContext is like GetGroups, using ctx for the request.
+func (c *Client) GetGroupsContext(ctx context.Context) ([]Group, error) {
+	req := GetGroupsRequest{
+		Selection: Selection{SelectionType: SelectionTypeRegistered},
+	}
+	j, err := json.Marshal(&req)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	body, err := c.get(ctx, c.url(groupPath), j)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching groups: %w", err)
+	}
+
+	var r GroupsResponse
+	if err = json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("error unmarshalling json: %v", err)
+	}
+
+	glog.V(1).Infof("GetGroups response: %#v", r)
+
+	if err := statusError(c.url(groupPath), r.Status); err != nil {
+		return nil, err
+	}
+	return r.Groups, nil
+}
+
+// UpdateGroups replaces all of the account's groups with groups, and
+// returns the result.  Groups without a GroupRef are created, and
+// existing groups left out of groups are deleted, so typically groups
+// is the result of GetGroups with changes applied.
+func (c *Client) UpdateGroups(groups []Group) ([]Group, error) {
+	return c.UpdateGroupsContext(context.Background(), groups)
+}
+
+// UpdateGroupsContext is like UpdateGroups, using ctx for the request.
+func (c *Client) UpdateGroupsContext(ctx context.Context, groups []Group) ([]Group, error) {
+	// Sending new groups twice would create them twice.
+	idempotent := true
+	for _, g := range groups {
+		if g.GroupName == "" {
+			return nil, fmt.Errorf("group name must not be empty")
+		}
+		if g.GroupRef == "" {
+			idempotent = false
+		}
+	}
+	if groups == nil {
+		groups = []Group{}
+	}
+	req := UpdateGroupsRequest{
+		Selection: Selection{SelectionType: SelectionTypeRegistered},
+		Groups:    groups,
+	}
+	j, err := json.Marshal(&req)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	glog.V(1).Infof("UpdateGroups request: %s", j)
+
+	body, err := c.post(ctx, c.url(groupPath), j, idempotent)
+	if err != nil {
+		return nil, err
+	}
+
+	var r GroupsResponse
+	if err = json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("error unmarshalling json: %v", err)
+	}
+
+	glog.V(1).Infof("UpdateGroups response: %#v", r)
+
+	if err := statusError(c.url(groupPath), r.Status); err != nil {
+		return nil, err
+	}
+	return r.Groups, nil
+}
+
+// FindGroup returns the group in groups whose ref or name, ignoring
+// case, is nameOrRef, or nil.
+func FindGroup(groups []Group, nameOrRef string) *Group {
+	for i := range groups {
+		if groups[i].GroupRef == nameOrRef {
+			return &groups[i]
+		}
+	}
+	for i := range groups {
+		if strings.EqualFold(groups[i].GroupName, nameOrRef) {
+			return &groups[i]
+		}
+	}
+	return nil
+}
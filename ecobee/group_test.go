@@ -0,0 +1,133 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+func TestGroups(t *testing.T) {
+	_, c := newTestServer(t)
+
+	gs, err := c.GetGroups()
+	if err != nil || len(gs) != 0 {
+		t.Fatalf("GetGroups = %v, %v, want no groups", gs, err)
+	}
+
+	// Create.
+	g := ecobee.Group{GroupName: "Houses", Thermostats: []string{"123"}}
+	if err := g.SetSync("Schedule", true); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	gs, err = c.UpdateGroups([]ecobee.Group{g})
+	if err != nil {
+		t.Fatalf("UpdateGroups: %v", err)
+	}
+	if len(gs) != 1 || gs[0].GroupRef == "" {
+		t.Fatalf("UpdateGroups = %+v, want one group with a ref", gs)
+	}
+	ref := gs[0].GroupRef
+
+	// Change membership and sync flags of what GetGroups returns.
+	gs, err = c.GetGroups()
+	if err != nil {
+		t.Fatalf("GetGroups: %v", err)
+	}
+	h := ecobee.FindGroup(gs, "houses")
+	if h == nil {
+		t.Fatalf("FindGroup(houses) = nil in %+v", gs)
+	}
+	h.Thermostats = append(h.Thermostats, "456")
+	if err := h.SetSync("alerts", true); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	if err := h.SetSync("schedule", false); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	if _, err := c.UpdateGroups(gs); err != nil {
+		t.Fatalf("UpdateGroups: %v", err)
+	}
+
+	gs, err = c.GetGroups()
+	if err != nil {
+		t.Fatalf("GetGroups: %v", err)
+	}
+	if len(gs) != 1 {
+		t.Fatalf("GetGroups = %+v, want one group", gs)
+	}
+	g = gs[0]
+	if g.GroupRef != ref || g.GroupName != "Houses" {
+		t.Errorf("group = %s (%s), want Houses (%s)", g.GroupName, g.GroupRef, ref)
+	}
+	if want := []string{"123", "456"}; !reflect.DeepEqual(g.Thermostats, want) {
+		t.Errorf("thermostats = %v, want %v", g.Thermostats, want)
+	}
+	if want := []string{"alerts"}; !reflect.DeepEqual(g.Syncs(), want) {
+		t.Errorf("syncs = %v, want %v", g.Syncs(), want)
+	}
+	if !g.HasThermostat("456") || g.HasThermostat("789") {
+		t.Errorf("HasThermostat(456), HasThermostat(789) = %v, %v, want true, false", g.HasThermostat("456"), g.HasThermostat("789"))
+	}
+
+	// Leaving the group out deletes it.
+	if gs, err = c.UpdateGroups(nil); err != nil || len(gs) != 0 {
+		t.Errorf("UpdateGroups(nil) = %+v, %v, want no groups", gs, err)
+	}
+}
+
+func TestUpdateGroupsErrors(t *testing.T) {
+	_, c := newTestServer(t)
+	for _, tc := range []struct {
+		name  string
+		group ecobee.Group
+	}{
+		{"no name", ecobee.Group{Thermostats: []string{"123"}}},
+		{"unknown ref", ecobee.Group{GroupRef: "nosuch", GroupName: "Houses"}},
+		{"unknown thermostat", ecobee.Group{GroupName: "Houses", Thermostats: []string{"789"}}},
+	} {
+		if gs, err := c.UpdateGroups([]ecobee.Group{tc.group}); err == nil {
+			t.Errorf("%s: UpdateGroups = %+v, want error", tc.name, gs)
+		}
+	}
+}
+
+func TestGroupSync(t *testing.T) {
+	var g ecobee.Group
+	for _, tc := range []struct {
+		name    string
+		on      bool
+		want    []string
+		wantErr bool
+	}{
+		{name: "schedule", on: true, want: []string{"schedule"}},
+		{name: "SystemMode", on: true, want: []string{"schedule", "systemMode"}},
+		{name: "schedule", on: false, want: []string{"systemMode"}},
+		{name: "nosuch", on: true, want: []string{"systemMode"}, wantErr: true},
+	} {
+		err := g.SetSync(tc.name, tc.on)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("SetSync(%q, %v) = %v, want error %v", tc.name, tc.on, err, tc.wantErr)
+		}
+		if !reflect.DeepEqual(g.Syncs(), tc.want) {
+			t.Errorf("after SetSync(%q, %v), Syncs = %v, want %v", tc.name, tc.on, g.Syncs(), tc.want)
+		}
+	}
+	if !g.SynchronizeSystemMode || g.SynchronizeSchedule {
+		t.Errorf("group = %+v, want only SynchronizeSystemMode", g)
+	}
+}
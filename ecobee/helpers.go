@@ -18,14 +18,20 @@ package ecobee
 // useful helpers.
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
 
 func (c *Client) ResumeProgram(id string, resumeAll bool) error {
+	return c.ResumeProgramContext(context.Background(), id, resumeAll)
+}
+
+// ResumeProgramContext is like ResumeProgram, using ctx for the request.
+func (c *Client) ResumeProgramContext(ctx context.Context, id string, resumeAll bool) error {
 	r := &UpdateThermostatRequest{
 		Selection: Selection{
-			SelectionType:  "thermostats",
+			SelectionType:  SelectionTypeThermostats,
 			SelectionMatch: id,
 		},
 		Functions: []Function{
@@ -37,19 +43,31 @@ func (c *Client) ResumeProgram(id string, resumeAll bool) error {
 			},
 		},
 	}
-	return c.UpdateThermostat(*r)
+	return c.UpdateThermostatContext(ctx, *r)
 }
 
+// RunFan runs the fan for duration.
 func (c *Client) RunFan(id string, duration time.Duration) error {
-	end := time.Now().Add(duration)
+	return c.RunFanContext(context.Background(), id, duration)
+}
+
+// RunFanContext is like RunFan, using ctx for the request.
+func (c *Client) RunFanContext(ctx context.Context, id string, duration time.Duration) error {
+	return c.RunFanWithContext(ctx, id, HoldFor(duration))
+}
+
+// RunFanWith runs the fan for the hold described by o.
+func (c *Client) RunFanWith(id string, o HoldOptions) error {
+	return c.RunFanWithContext(context.Background(), id, o)
+}
+
+// RunFanWithContext is like RunFanWith, using ctx for the request.
+func (c *Client) RunFanWithContext(ctx context.Context, id string, o HoldOptions) error {
 	shp := SetHoldParams{
 		// these HoldTemps don't get used because the IsTemperature
 		// flags are both false.
 		CoolHoldTemp: 800,
 		HeatHoldTemp: 690,
-		HoldType:     "dateTime",
-		EndTime:      end.Format("15:04:05"),
-		EndDate:      end.Format("2006-01-02"),
 		Event: Event{
 			Fan:                   "on",
 			IsTemperatureRelative: false,
@@ -57,23 +75,15 @@ func (c *Client) RunFan(id string, duration time.Duration) error {
 			OccupiedSensorActive:  false,
 		},
 	}
-	r := &UpdateThermostatRequest{
-		Selection: Selection{
-			SelectionType:  "thermostats",
-			SelectionMatch: id,
-		},
-		Functions: []Function{
-			{
-				Type:   "setHold",
-				Params: shp,
-			},
-		},
-	}
-
-	return c.UpdateThermostat(*r)
+	return c.setHold(ctx, id, shp, o)
 }
 
 func (c *Client) SendMessage(thermostat, message string) error {
+	return c.SendMessageContext(context.Background(), thermostat, message)
+}
+
+// SendMessageContext is like SendMessage, using ctx for the request.
+func (c *Client) SendMessageContext(ctx context.Context, thermostat, message string) error {
 	smp := SendMessageParams{
 		Alert: Alert{
 			AlertType:       "message",
@@ -84,7 +94,7 @@ func (c *Client) SendMessage(thermostat, message string) error {
 
 	r := &UpdateThermostatRequest{
 		Selection: Selection{
-			SelectionType:  "thermostats",
+			SelectionType:  SelectionTypeThermostats,
 			SelectionMatch: thermostat,
 		},
 		Functions: []Function{
@@ -95,15 +105,16 @@ func (c *Client) SendMessage(thermostat, message string) error {
 		},
 	}
 
-	return c.UpdateThermostat(*r)
+	return c.UpdateThermostatContext(ctx, *r)
 }
 
-// The Ecobee API represents temperatures as integers.
-func makeTemp(h, c float64) (int, int) {
-	return int(h * 10), int(c * 10)
-}
+// Limits on hold temperatures accepted by HoldTemperature.
+var (
+	maxHeatHoldTemp = FromFahrenheit(90)
+	minCoolHoldTemp = FromFahrenheit(60)
+)
 
-func tempCheck(heat, cool float64) error {
+func tempCheck(heat, cool Temperature) error {
 	// Note: two properties Runtime.desiredCoolRange and
 	// Runtime.desiredHeatRange indicate the current valid temperature
 	// ranges. These fields should be queried before using the SetHold
@@ -118,34 +129,59 @@ func tempCheck(heat, cool float64) error {
 	if cool == 0 {
 		return fmt.Errorf("cool must not be 0")
 	}
-	if heat > 90 {
-		return fmt.Errorf("heat %.1f above limit %d", heat, 90)
+	if heat > maxHeatHoldTemp {
+		return fmt.Errorf("heat %s (%s) above limit %s (%s)", heat, heat.Format(Celsius)+"°C",
+			maxHeatHoldTemp, maxHeatHoldTemp.Format(Celsius)+"°C")
 	}
-	if cool < 60 {
-		return fmt.Errorf("cool %.1f below limit %d", cool, 60)
+	if cool < minCoolHoldTemp {
+		return fmt.Errorf("cool %s (%s) below limit %s (%s)", cool, cool.Format(Celsius)+"°C",
+			minCoolHoldTemp, minCoolHoldTemp.Format(Celsius)+"°C")
 	}
 	if cool < heat {
-		return fmt.Errorf("heat %.1f must be below cool %.1f", heat, cool)
+		return fmt.Errorf("heat %s must be below cool %s", heat, cool)
 	}
 	return nil
 }
 
 func (c *Client) HoldTemp(thermostat string, heat, cool float64, d time.Duration) error {
-	end := time.Now().Add(d)
+	return c.HoldTempContext(context.Background(), thermostat, heat, cool, d)
+}
+
+// HoldTempContext is like HoldTemp, using ctx for the request.
+func (c *Client) HoldTempContext(ctx context.Context, thermostat string, heat, cool float64, d time.Duration) error {
+	return c.HoldTemperatureContext(ctx, thermostat, FromFahrenheit(heat), FromFahrenheit(cool), d)
+}
 
+// HoldTemperature holds the thermostat between the heat and cool
+// setpoints for duration d.  Unlike HoldTemp, the setpoints can be
+// built in either unit, e.g. with FromCelsius.
+func (c *Client) HoldTemperature(thermostat string, heat, cool Temperature, d time.Duration) error {
+	return c.HoldTemperatureContext(context.Background(), thermostat, heat, cool, d)
+}
+
+// HoldTemperatureContext is like HoldTemperature, using ctx for the
+// request.
+func (c *Client) HoldTemperatureContext(ctx context.Context, thermostat string, heat, cool Temperature, d time.Duration) error {
+	return c.HoldTemperatureWithContext(ctx, thermostat, heat, cool, HoldFor(d))
+}
+
+// HoldTemperatureWith holds the thermostat between the heat and cool
+// setpoints for the hold described by o.
+func (c *Client) HoldTemperatureWith(thermostat string, heat, cool Temperature, o HoldOptions) error {
+	return c.HoldTemperatureWithContext(context.Background(), thermostat, heat, cool, o)
+}
+
+// HoldTemperatureWithContext is like HoldTemperatureWith, using ctx for
+// the request.
+func (c *Client) HoldTemperatureWithContext(ctx context.Context, thermostat string, heat, cool Temperature, o HoldOptions) error {
 	if err := tempCheck(heat, cool); err != nil {
 		return err
 	}
 
-	ht, cl := makeTemp(heat, cool)
-
 	shp := SetHoldParams{
-		HeatHoldTemp: ht,
-		CoolHoldTemp: cl,
+		HeatHoldTemp: heat,
+		CoolHoldTemp: cool,
 
-		HoldType: "dateTime",
-		EndTime:  end.Format("15:04:05"),
-		EndDate:  end.Format("2006-01-02"),
 		Event: Event{
 			Fan: "auto",
 			// relative temperatures don't seem to work with the API.
@@ -154,19 +190,5 @@ func (c *Client) HoldTemp(thermostat string, heat, cool float64, d time.Duration
 			OccupiedSensorActive:  false,
 		},
 	}
-
-	r := &UpdateThermostatRequest{
-		Selection: Selection{
-			SelectionType:  "thermostats",
-			SelectionMatch: thermostat,
-		},
-		Functions: []Function{
-			{
-				Type:   "setHold",
-				Params: shp,
-			},
-		},
-	}
-
-	return c.UpdateThermostat(*r)
+	return c.setHold(ctx, thermostat, shp, o)
 }
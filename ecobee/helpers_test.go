@@ -0,0 +1,38 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "testing"
+
+func TestTempCheck(t *testing.T) {
+	for _, tc := range []struct {
+		heat, cool Temperature
+		wantErr    bool
+	}{
+		{620, 800, false},
+		{700, 700, false},
+		{maxHeatHoldTemp, maxHeatHoldTemp, false},
+		{minCoolHoldTemp, minCoolHoldTemp, false},
+		{0, 800, true},
+		{620, 0, true},
+		{maxHeatHoldTemp + 1, 920, true},
+		{500, minCoolHoldTemp - 1, true},
+		{750, 700, true},
+	} {
+		if err := tempCheck(tc.heat, tc.cool); (err != nil) != tc.wantErr {
+			t.Errorf("tempCheck(%s, %s) = %v, want error %v", tc.heat, tc.cool, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The management hierarchy of EMS accounts organizes thermostats into
+// a tree of sets named by paths such as "/Toronto/Condos".  The root set
+// is "/".  Thermostats in a set and its descendants can be selected with
+// WithManagementSet.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// HierarchySet is a management set.
+type HierarchySet struct {
+	SetName     string         `json:"setName"`
+	SetRef      string         `json:"setRef"`
+	SetPath     string         `json:"setPath"`
+	ParentPath  string         `json:"parentPath"`
+	Children    []HierarchySet `json:"children"`
+	Thermostats []string       `json:"thermostats"`
+}
+
+// HierarchyUser is a user of an EMS account.
+type HierarchyUser struct {
+	UserName    string `json:"userName"`
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+	Phone       string `json:"phone"`
+	EmailAlerts bool   `json:"emailAlerts"`
+}
+
+// HierarchyPrivilege is what a user may do in a set.
+type HierarchyPrivilege struct {
+	SetPath        string `json:"setPath"`
+	UserName       string `json:"userName"`
+	AllowHierarchy bool   `json:"allowHierarchy"`
+	AllowAccess    bool   `json:"allowAccess"`
+	AllowAlerts    bool   `json:"allowAlerts"`
+	AllowEvents    bool   `json:"allowEvents"`
+}
+
+// HierarchySetRequest is a request of the hierarchy/set endpoint.
+// Which fields are used depends on Operation: "list", "add", "remove",
+// "rename" or "move".
+type HierarchySetRequest struct {
+	Operation  string `json:"operation"`
+	SetName    string `json:"setName,omitempty"`
+	SetPath    string `json:"setPath,omitempty"`
+	ParentPath string `json:"parentPath,omitempty"`
+	ToPath     string `json:"toPath,omitempty"`
+	NewName    string `json:"newName,omitempty"`
+	Recursive  bool   `json:"recursive,omitempty"`
+}
+
+type HierarchySetResponse struct {
+	Sets   []HierarchySet `json:"sets"`
+	Status Status         `json:"status"`
+}
+
+// HierarchyThermostatRequest is a request of the hierarchy/thermostat
+// endpoint.  Operation is "assign" or "move"; Thermostats is a comma
+// separated list of identifiers.
+type HierarchyThermostatRequest struct {
+	Operation   string `json:"operation"`
+	SetPath     string `json:"setPath,omitempty"`
+	FromPath    string `json:"fromPath,omitempty"`
+	ToPath      string `json:"toPath,omitempty"`
+	Thermostats string `json:"thermostats"`
+}
+
+// HierarchyUserRequest is a request of the hierarchy/user endpoint.
+// Only the "list" operation is supported.
+type HierarchyUserRequest struct {
+	Operation         string `json:"operation"`
+	SetPath           string `json:"setPath,omitempty"`
+	Recursive         bool   `json:"recursive,omitempty"`
+	IncludePrivileges bool   `json:"includePrivileges,omitempty"`
+}
+
+type HierarchyUserResponse struct {
+	Users      []HierarchyUser      `json:"users"`
+	Privileges []HierarchyPrivilege `json:"privileges"`
+	Status     Status               `json:"status"`
+}
+
+// postHierarchy sends req to the hierarchy endpoint path and decodes
+// the response into resp, if it isn't nil.
+func (c *Client) postHierarchy(ctx context.Context, path string, req interface{}, idempotent bool, resp interface{}) error {
+	j, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	glog.V(1).Infof("hierarchy request to %s: %s", path, j)
+
+	body, err := c.post(ctx, c.url(path), j, idempotent)
+	if err != nil {
+		return err
+	}
+
+	var s UpdateThermostatResponse
+	if err = json.Unmarshal(body, &s); err != nil {
+		return fmt.Errorf("error unmarshalling json: %v", err)
+	}
+	if err := statusError(c.url(path), s.Status); err != nil {
+		return err
+	}
+	if resp != nil {
+		if err = json.Unmarshal(body, resp); err != nil {
+			return fmt.Errorf("error unmarshalling json: %v", err)
+		}
+	}
+	return nil
+}
+
+// ListSets fetches the management set at path, "/" for the whole
+// hierarchy.  Its children are included, and if recursive is set,
+// theirs too.
+func (c *Client) ListSets(path string, recursive bool) ([]HierarchySet, error) {
+	return c.ListSetsContext(context.Background(), path, recursive)
+}
+
+// ListSetsContext is like ListSets, using ctx for the request.
+func (c *Client) ListSetsContext(ctx context.Context, path string, recursive bool) ([]HierarchySet, error) {
+	if err := checkSetPath(path); err != nil {
+		return nil, err
+	}
+	var r HierarchySetResponse
+	err := c.postHierarchy(ctx, hierarchySetPath, HierarchySetRequest{
+		Operation: "list",
+		SetPath:   path,
+		Recursive: recursive,
+	}, true, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r.Sets, nil
+}
+
+// AddSet adds a management set named name under parentPath.
+func (c *Client) AddSet(name, parentPath string) error {
+	return c.AddSetContext(context.Background(), name, parentPath)
+}
+
+// AddSetContext is like AddSet, using ctx for the request.
+func (c *Client) AddSetContext(ctx context.Context, name, parentPath string) error {
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid set name %q", name)
+	}
+	if err := checkSetPath(parentPath); err != nil {
+		return err
+	}
+	return c.postHierarchy(ctx, hierarchySetPath, HierarchySetRequest{
+		Operation:  "add",
+		SetName:    name,
+		ParentPath: parentPath,
+	}, false, nil)
+}
+
+// RemoveSet removes the management set at path.
+func (c *Client) RemoveSet(path string) error {
+	return c.RemoveSetContext(context.Background(), path)
+}
+
+// RemoveSetContext is like RemoveSet, using ctx for the request.
+func (c *Client) RemoveSetContext(ctx context.Context, path string) error {
+	if err := checkSetPath(path); err != nil {
+		return err
+	}
+	if path == "/" {
+		return fmt.Errorf("the root set can't be removed")
+	}
+	return c.postHierarchy(ctx, hierarchySetPath, HierarchySetRequest{
+		Operation: "remove",
+		SetPath:   path,
+	}, false, nil)
+}
+
+// MoveSet moves the management set at path, with its children and
+// thermostats, under toPath.
+func (c *Client) MoveSet(path, toPath string) error {
+	return c.MoveSetContext(context.Background(), path, toPath)
+}
+
+// MoveSetContext is like MoveSet, using ctx for the request.
+func (c *Client) MoveSetContext(ctx context.Context, path, toPath string) error {
+	for _, p := range []string{path, toPath} {
+		if err := checkSetPath(p); err != nil {
+			return err
+		}
+	}
+	return c.postHierarchy(ctx, hierarchySetPath, HierarchySetRequest{
+		Operation: "move",
+		SetPath:   path,
+		ToPath:    toPath,
+	}, false, nil)
+}
+
+// AssignThermostats assigns thermostats, a comma separated list of
+// identifiers, to the management set at path.
+func (c *Client) AssignThermostats(path, thermostats string) error {
+	return c.AssignThermostatsContext(context.Background(), path, thermostats)
+}
+
+// AssignThermostatsContext is like AssignThermostats, using ctx for the
+// request.
+func (c *Client) AssignThermostatsContext(ctx context.Context, path, thermostats string) error {
+	if err := checkSetPath(path); err != nil {
+		return err
+	}
+	return c.postHierarchy(ctx, hierarchyThermostatPath, HierarchyThermostatRequest{
+		Operation:   "assign",
+		SetPath:     path,
+		Thermostats: thermostats,
+	}, true, nil)
+}
+
+// MoveThermostats moves thermostats, a comma separated list of
+// identifiers, from the management set at fromPath to the one at
+// toPath.
+func (c *Client) MoveThermostats(fromPath, toPath, thermostats string) error {
+	return c.MoveThermostatsContext(context.Background(), fromPath, toPath, thermostats)
+}
+
+// MoveThermostatsContext is like MoveThermostats, using ctx for the
+// request.
+func (c *Client) MoveThermostatsContext(ctx context.Context, fromPath, toPath, thermostats string) error {
+	for _, p := range []string{fromPath, toPath} {
+		if err := checkSetPath(p); err != nil {
+			return err
+		}
+	}
+	return c.postHierarchy(ctx, hierarchyThermostatPath, HierarchyThermostatRequest{
+		Operation:   "move",
+		FromPath:    fromPath,
+		ToPath:      toPath,
+		Thermostats: thermostats,
+	}, true, nil)
+}
+
+// UnassignThermostats moves thermostats, a comma separated list of
+// identifiers, from the management set at path back to the root set.
+func (c *Client) UnassignThermostats(path, thermostats string) error {
+	return c.UnassignThermostatsContext(context.Background(), path, thermostats)
+}
+
+// UnassignThermostatsContext is like UnassignThermostats, using ctx for
+// the request.
+func (c *Client) UnassignThermostatsContext(ctx context.Context, path, thermostats string) error {
+	return c.MoveThermostatsContext(ctx, path, "/", thermostats)
+}
+
+// ListUsers fetches the users with access to the management set at
+// path, and of its descendants if recursive is set, with their
+// privileges.
+func (c *Client) ListUsers(path string, recursive bool) ([]HierarchyUser, []HierarchyPrivilege, error) {
+	return c.ListUsersContext(context.Background(), path, recursive)
+}
+
+// ListUsersContext is like ListUsers, using ctx for the request.
+func (c *Client) ListUsersContext(ctx context.Context, path string, recursive bool) ([]HierarchyUser, []HierarchyPrivilege, error) {
+	if err := checkSetPath(path); err != nil {
+		return nil, nil, err
+	}
+	var r HierarchyUserResponse
+	err := c.postHierarchy(ctx, hierarchyUserPath, HierarchyUserRequest{
+		Operation:         "list",
+		SetPath:           path,
+		Recursive:         recursive,
+		IncludePrivileges: true,
+	}, true, &r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.Users, r.Privileges, nil
+}
+
+// checkSetPath checks that path is a management set path.
+func checkSetPath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("management set path must start with /, got %q", path)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+// setTree returns the paths of sets and their descendants, each with
+// its ref and thermostats.
+func setTree(sets []ecobee.HierarchySet) []string {
+	var lines []string
+	for _, s := range sets {
+		lines = append(lines, s.SetPath+" "+s.SetRef+": "+strings.Join(s.Thermostats, ","))
+		lines = append(lines, setTree(s.Children)...)
+	}
+	return lines
+}
+
+func TestHierarchySets(t *testing.T) {
+	_, c := newTestServer(t)
+
+	for _, step := range []struct {
+		name string
+		op   func() error
+		want []string
+	}{
+		{"empty", func() error { return nil }, []string{"/ root: 123,456"}},
+		{"add", func() error { return c.AddSet("Toronto", "/") }, []string{
+			"/ root: 123,456",
+			"/Toronto set1: ",
+		}},
+		{"add child", func() error { return c.AddSet("Condos", "/Toronto") }, []string{
+			"/ root: 123,456",
+			"/Toronto set1: ",
+			"/Toronto/Condos set2: ",
+		}},
+		{"assign", func() error { return c.AssignThermostats("/Toronto/Condos", "123,456") }, []string{
+			"/ root: ",
+			"/Toronto set1: ",
+			"/Toronto/Condos set2: 123,456",
+		}},
+		{"add sibling", func() error { return c.AddSet("Ottawa", "/") }, []string{
+			"/ root: ",
+			"/Ottawa set3: ",
+			"/Toronto set1: ",
+			"/Toronto/Condos set2: 123,456",
+		}},
+		{"move set", func() error { return c.MoveSet("/Toronto/Condos", "/Ottawa") }, []string{
+			"/ root: ",
+			"/Ottawa set3: ",
+			"/Ottawa/Condos set2: 123,456",
+			"/Toronto set1: ",
+		}},
+		{"move thermostat", func() error { return c.MoveThermostats("/Ottawa/Condos", "/Toronto", "456") }, []string{
+			"/ root: ",
+			"/Ottawa set3: ",
+			"/Ottawa/Condos set2: 123",
+			"/Toronto set1: 456",
+		}},
+		{"unassign", func() error { return c.UnassignThermostats("/Ottawa/Condos", "123") }, []string{
+			"/ root: 123",
+			"/Ottawa set3: ",
+			"/Ottawa/Condos set2: ",
+			"/Toronto set1: 456",
+		}},
+		{"remove", func() error { return c.RemoveSet("/Ottawa/Condos") }, []string{
+			"/ root: 123",
+			"/Ottawa set3: ",
+			"/Toronto set1: 456",
+		}},
+	} {
+		if err := step.op(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		sets, err := c.ListSets("/", true)
+		if err != nil {
+			t.Fatalf("%s: ListSets: %v", step.name, err)
+		}
+		if got := setTree(sets); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: sets = %q, want %q", step.name, got, step.want)
+		}
+	}
+
+	// Without recursive, only the set and its children are listed.
+	sets, err := c.ListSets("/Ottawa", false)
+	if err != nil {
+		t.Fatalf("ListSets: %v", err)
+	}
+	if got, want := setTree(sets), []string{"/Ottawa set3: "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListSets(/Ottawa) = %q, want %q", got, want)
+	}
+}
+
+func TestHierarchyErrors(t *testing.T) {
+	_, c := newTestServer(t)
+	if err := c.AddSet("Toronto", "/"); err != nil {
+		t.Fatalf("AddSet: %v", err)
+	}
+	if err := c.AssignThermostats("/Toronto", "123"); err != nil {
+		t.Fatalf("AssignThermostats: %v", err)
+	}
+	for _, tc := range []struct {
+		name string
+		op   func() error
+	}{
+		{"list unknown set", func() error { _, err := c.ListSets("/Nowhere", false); return err }},
+		{"list relative path", func() error { _, err := c.ListSets("Toronto", false); return err }},
+		{"add to unknown set", func() error { return c.AddSet("Condos", "/Nowhere") }},
+		{"add existing set", func() error { return c.AddSet("Toronto", "/") }},
+		{"remove unknown set", func() error { return c.RemoveSet("/Nowhere") }},
+		{"remove set with thermostats", func() error { return c.RemoveSet("/Toronto") }},
+		{"move into itself", func() error { return c.MoveSet("/Toronto", "/Toronto") }},
+		{"move to unknown set", func() error { return c.MoveSet("/Toronto", "/Nowhere") }},
+		{"assign to unknown set", func() error { return c.AssignThermostats("/Nowhere", "456") }},
+		{"assign unknown thermostat", func() error { return c.AssignThermostats("/Toronto", "789") }},
+		{"unassign from another set", func() error { return c.UnassignThermostats("/Toronto", "456") }},
+		{"list users of unknown path", func() error { _, _, err := c.ListUsers("Toronto", false); return err }},
+	} {
+		if err := tc.op(); err == nil {
+			t.Errorf("%s succeeded, want error", tc.name)
+		}
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	s, c := newTestServer(t)
+	s.AddHierarchyUser(ecobee.HierarchyUser{UserName: "ann@example.com", FirstName: "Ann"},
+		ecobee.HierarchyPrivilege{SetPath: "/", AllowHierarchy: true, AllowAccess: true})
+	s.AddHierarchyUser(ecobee.HierarchyUser{UserName: "bob@example.com", FirstName: "Bob"},
+		ecobee.HierarchyPrivilege{SetPath: "/Toronto", AllowAlerts: true})
+
+	for _, tc := range []struct {
+		path      string
+		recursive bool
+		want      []string
+		wantPaths []string
+	}{
+		{"/", false, []string{"ann@example.com"}, []string{"/"}},
+		{"/", true, []string{"ann@example.com", "bob@example.com"}, []string{"/", "/Toronto"}},
+		{"/Toronto", false, []string{"bob@example.com"}, []string{"/Toronto"}},
+		{"/Ottawa", true, nil, nil},
+	} {
+		users, privs, err := c.ListUsers(tc.path, tc.recursive)
+		if err != nil {
+			t.Errorf("ListUsers(%q, %v): %v", tc.path, tc.recursive, err)
+			continue
+		}
+		var got, gotPaths []string
+		for _, u := range users {
+			got = append(got, u.UserName)
+		}
+		for _, p := range privs {
+			gotPaths = append(gotPaths, p.SetPath)
+		}
+		if !reflect.DeepEqual(got, tc.want) || !reflect.DeepEqual(gotPaths, tc.wantPaths) {
+			t.Errorf("ListUsers(%q, %v) = %v with privileges in %v, want %v in %v", tc.path, tc.recursive, got, gotPaths, tc.want, tc.wantPaths)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HoldType is how long a hold lasts.
+type HoldType string
+
+const (
+	// HoldDateTime holds until a given date and time.
+	HoldDateTime HoldType = "dateTime"
+	// HoldNextTransition holds until the program's next climate
+	// change.
+	HoldNextTransition HoldType = "nextTransition"
+	// HoldIndefinite holds until the program is resumed.
+	HoldIndefinite HoldType = "indefinite"
+	// HoldHours holds for a whole number of hours.
+	HoldHours HoldType = "holdHours"
+)
+
+// HoldOptions describe when a hold starts and ends.
+type HoldOptions struct {
+	// Type is the kind of hold.  The default is HoldDateTime.
+	Type HoldType
+	// Start schedules the hold to start in the future.  A zero Start
+	// starts the hold now.
+	Start time.Time
+	// End is the end of a HoldDateTime hold.  If it is zero, the hold
+	// lasts Duration from its start.
+	End time.Time
+	// Duration is the length of a HoldDateTime hold without an End,
+	// and of a HoldHours hold, where it is rounded to whole hours.
+	Duration time.Duration
+}
+
+// HoldFor returns the options for a hold lasting d from now.
+func HoldFor(d time.Duration) HoldOptions {
+	return HoldOptions{Type: HoldDateTime, Duration: d}
+}
+
+// apply fills in the hold type, start, end and length fields of shp.
+// loc is the thermostat's time zone.
+func (o HoldOptions) apply(shp *SetHoldParams, loc *time.Location, now time.Time) error {
+	t := o.Type
+	if t == "" {
+		t = HoldDateTime
+	}
+	shp.HoldType = string(t)
+
+	start := now
+	if !o.Start.IsZero() {
+		if o.Start.Before(now.Add(-time.Minute)) {
+			return fmt.Errorf("hold start %s is in the past", o.Start.In(loc).Format(DateTimeLayout))
+		}
+		start = o.Start
+		shp.StartDate, shp.StartTime = FormatDateTime(start, loc)
+	}
+
+	switch t {
+	case HoldDateTime:
+		end := o.End
+		if end.IsZero() {
+			if o.Duration <= 0 {
+				return fmt.Errorf("hold needs an end time or a positive duration")
+			}
+			end = start.Add(o.Duration)
+		}
+		if !end.After(start) {
+			return fmt.Errorf("hold end %s is not after its start %s",
+				end.In(loc).Format(DateTimeLayout), start.In(loc).Format(DateTimeLayout))
+		}
+		shp.EndDate, shp.EndTime = FormatDateTime(end, loc)
+	case HoldHours:
+		shp.HoldHours = int(o.Duration.Round(time.Hour) / time.Hour)
+		if shp.HoldHours < 1 {
+			return fmt.Errorf("holdHours hold must last at least an hour, got %v", o.Duration)
+		}
+	case HoldNextTransition, HoldIndefinite:
+	default:
+		return fmt.Errorf("invalid hold type %q", t)
+	}
+	return nil
+}
+
+// setHold applies the timing of o to shp and sends it to thermostat.
+func (c *Client) setHold(ctx context.Context, thermostat string, shp SetHoldParams, o HoldOptions) error {
+	loc, err := c.ThermostatTimeZone(ctx, thermostat)
+	if err != nil {
+		return fmt.Errorf("error determining time zone: %w", err)
+	}
+	return c.setHoldIn(ctx, thermostat, loc, shp, o)
+}
+
+// setHoldIn is like setHold, for a thermostat in time zone loc.
+func (c *Client) setHoldIn(ctx context.Context, thermostat string, loc *time.Location, shp SetHoldParams, o HoldOptions) error {
+	if err := o.apply(&shp, loc, time.Now()); err != nil {
+		return err
+	}
+	var params interface{} = shp
+	if shp.HoldClimateRef != "" {
+		// The climate sets the temperatures and fan, so the zero ones
+		// of shp are left out.
+		params = climateHoldParams{
+			HoldClimateRef: shp.HoldClimateRef,
+			StartDate:      shp.StartDate,
+			StartTime:      shp.StartTime,
+			EndDate:        shp.EndDate,
+			EndTime:        shp.EndTime,
+			HoldType:       shp.HoldType,
+			HoldHours:      shp.HoldHours,
+		}
+	}
+
+	r := &UpdateThermostatRequest{
+		Selection: Selection{
+			SelectionType:  SelectionTypeThermostats,
+			SelectionMatch: thermostat,
+		},
+		Functions: []Function{
+			{
+				Type:   "setHold",
+				Params: params,
+			},
+		},
+	}
+
+	return c.UpdateThermostatContext(ctx, *r)
+}
+
+// climateHoldParams are the setHold parameters of a hold at a climate.
+type climateHoldParams struct {
+	HoldClimateRef string `json:"holdClimateRef"`
+	StartDate      string `json:"startDate,omitempty"`
+	StartTime      string `json:"startTime,omitempty"`
+	EndDate        string `json:"endDate,omitempty"`
+	EndTime        string `json:"endTime,omitempty"`
+	HoldType       string `json:"holdType,omitempty"`
+	HoldHours      int    `json:"holdHours,omitempty"`
+}
+
+// HoldClimate holds the thermostat at the comfort setting climate, the
+// ref or name of one of its Program.Climates, e.g. "away", for the hold
+// described by o.
+func (c *Client) HoldClimate(thermostat, climate string, o HoldOptions) error {
+	return c.HoldClimateContext(context.Background(), thermostat, climate, o)
+}
+
+// HoldClimateContext is like HoldClimate, using ctx for the request.
+func (c *Client) HoldClimateContext(ctx context.Context, thermostat, climate string, o HoldOptions) error {
+	// One request fetches both the climates and the time zone.
+	ts, err := c.GetThermostatsContext(ctx, Selection{
+		SelectionType:   SelectionTypeThermostats,
+		SelectionMatch:  thermostat,
+		IncludeProgram:  true,
+		IncludeLocation: true,
+	})
+	if err != nil {
+		return err
+	}
+	if len(ts) != 1 {
+		return fmt.Errorf("got %d thermostats, wanted 1", len(ts))
+	}
+	t := &ts[0]
+	cl := t.Program.FindClimate(climate)
+	if cl == nil {
+		return fmt.Errorf("thermostat %s has no climate %q (known: %v)", thermostat, climate, t.Program.ClimateRefs())
+	}
+	loc, err := t.TimeZone()
+	if err != nil {
+		return fmt.Errorf("error determining time zone: %w", err)
+	}
+	return c.setHoldIn(ctx, thermostat, loc, SetHoldParams{HoldClimateRef: cl.ClimateRef}, o)
+}
@@ -0,0 +1,198 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// MeterEnergy is the meter type of energy consumption readings, the
+// only type ecobee currently reports.
+const MeterEnergy = "energy"
+
+// MeterReportRequest is the request of the meterReport endpoint.  Dates
+// and intervals are as in RuntimeReportRequest.
+type MeterReportRequest struct {
+	Selection     Selection `json:"selection"`
+	StartDate     string    `json:"startDate"`
+	StartInterval int       `json:"startInterval"`
+	EndDate       string    `json:"endDate"`
+	EndInterval   int       `json:"endInterval"`
+	Meters        string    `json:"meters"`
+}
+
+type MeterReportResponse struct {
+	ReportList []MeterReport `json:"reportList"`
+	Status     Status        `json:"status"`
+}
+
+// MeterReport is the meter data of one thermostat.  Thermostats
+// without utility metering have no meters.
+type MeterReport struct {
+	ThermostatIdentifier string            `json:"thermostatIdentifier"`
+	MeterList            []MeterReportData `json:"meterList"`
+}
+
+// MeterReportData are the readings of one meter.  Each is
+// "date,time,value" in the thermostat's time zone.
+type MeterReportData struct {
+	MeterType string   `json:"meterType"`
+	Data      []string `json:"data"`
+}
+
+// MeterReading is the consumption measured by a meter during one 5
+// minute interval.
+type MeterReading struct {
+	Thermostat string
+	Meter      string
+	Time       time.Time
+	Value      float64
+}
+
+// GetMeterReport fetches the meter readings of thermostats, a comma
+// separated list of identifiers, for the 5 minute intervals from start
+// up to end.  meters are meter types such as MeterEnergy.  Long ranges
+// and many thermostats are split into several requests.  Intervals
+// without a reading are left out.  The readings are ordered by
+// thermostat, as requested, meter and time.
+func (c *Client) GetMeterReport(thermostats string, start, end time.Time, meters []string) ([]MeterReading, error) {
+	return c.GetMeterReportContext(context.Background(), thermostats, start, end, meters)
+}
+
+// GetMeterReportContext is like GetMeterReport, using ctx for the
+// requests.
+func (c *Client) GetMeterReportContext(ctx context.Context, thermostats string, start, end time.Time, meters []string) ([]MeterReading, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("report end %v is not after start %v", end, start)
+	}
+	if len(meters) == 0 {
+		meters = []string{MeterEnergy}
+	}
+	ids := strings.Split(thermostats, ",")
+
+	var rs []MeterReading
+	for _, batch := range reportBatches(ids) {
+		for _, w := range reportWindows(start, end) {
+			req := MeterReportRequest{
+				Selection: Selection{
+					SelectionType:  SelectionTypeThermostats,
+					SelectionMatch: batch,
+				},
+				StartDate:     w.startDate,
+				StartInterval: w.startInterval,
+				EndDate:       w.endDate,
+				EndInterval:   w.endInterval,
+				Meters:        strings.Join(meters, ","),
+			}
+			r, err := c.meterReport(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			rs = append(rs, r...)
+		}
+	}
+
+	order := map[string]int{}
+	for i, id := range ids {
+		order[id] = i
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		a, b := rs[i], rs[j]
+		if a.Thermostat != b.Thermostat {
+			return order[a.Thermostat] < order[b.Thermostat]
+		}
+		if a.Meter != b.Meter {
+			return a.Meter < b.Meter
+		}
+		return a.Time.Before(b.Time)
+	})
+	return rs, nil
+}
+
+// meterReport sends a single report request and returns its readings.
+func (c *Client) meterReport(ctx context.Context, req MeterReportRequest) ([]MeterReading, error) {
+	if err := req.Selection.Validate(); err != nil {
+		return nil, err
+	}
+	j, err := json.Marshal(&req)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	body, err := c.getReport(ctx, c.url(meterReportPath), j)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching meter report: %w", err)
+	}
+
+	var resp MeterReportResponse
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling json: %v", err)
+	}
+
+	glog.V(1).Infof("GetMeterReport response: %d reports", len(resp.ReportList))
+
+	if err := statusError(c.url(meterReportPath), resp.Status); err != nil {
+		return nil, err
+	}
+
+	var rs []MeterReading
+	var zones map[string]*time.Location
+	for _, mr := range resp.ReportList {
+		if len(mr.MeterList) == 0 {
+			continue
+		}
+		if zones == nil {
+			// One request resolves the time zones of the whole batch.
+			if zones, err = c.ThermostatTimeZones(ctx, req.Selection.SelectionMatch); err != nil {
+				return nil, err
+			}
+		}
+		loc, err := reportZone(zones, mr.ThermostatIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mr.MeterList {
+			rows, err := parseReportRows(mr.ThermostatIdentifier, []string{"value"}, m.Data, loc)
+			if err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				v, ok := row.Values["value"]
+				if !ok {
+					continue
+				}
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s reading %q for thermostat %s", m.MeterType, v, mr.ThermostatIdentifier)
+				}
+				rs = append(rs, MeterReading{
+					Thermostat: mr.ThermostatIdentifier,
+					Meter:      m.MeterType,
+					Time:       row.Time,
+					Value:      f,
+				})
+			}
+		}
+	}
+	return rs, nil
+}
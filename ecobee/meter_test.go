@@ -0,0 +1,117 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rspier/go-ecobee/ecobee"
+	"github.com/rspier/go-ecobee/ecobee/ecobeetest"
+)
+
+// newMeterServer starts a fake with thermostats 100 to 100+n-1, where
+// each thermostat in metered reports energy of its number divided by
+// 100, and returns it with a client whose requests are counted.
+func newMeterServer(t *testing.T, n int, metered ...string) (*ecobee.Client, *countingTransport, []string) {
+	t.Helper()
+	s := ecobeetest.NewServer()
+	t.Cleanup(s.Close)
+	var ids []string
+	for i := 0; i < n; i++ {
+		id := fmt.Sprint(100 + i)
+		s.AddThermostat(testThermostat(id, "Thermostat "+id))
+		ids = append(ids, id)
+	}
+	for _, id := range metered {
+		var v float64
+		fmt.Sscan(id, &v)
+		s.SetMeter(id, ecobee.MeterEnergy, v/100)
+	}
+	ct := &countingTransport{}
+	c, err := s.NewClient(filepath.Join(t.TempDir(), "authcache"), ecobee.WithTransport(ct))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c, ct, ids
+}
+
+func TestGetMeterReport(t *testing.T) {
+	start := time.Date(2017, 12, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name           string
+		n              int
+		metered        []string
+		span           time.Duration
+		wantReports    int // meterReport requests
+		wantZones      int // thermostat requests for time zones
+		wantPerMetered int
+	}{
+		{"one", 1, []string{"100"}, 15 * time.Minute, 1, 1, 3},
+		{"unmetered", 2, nil, 15 * time.Minute, 1, 0, 0},
+		{"25 thermostats", 25, []string{"100", "124"}, time.Hour, 1, 1, 12},
+		{"26 thermostats", 26, []string{"100", "125"}, time.Hour, 2, 2, 12},
+		{"30 days", 1, []string{"100"}, 30 * 24 * time.Hour, 1, 1, 30 * 288},
+		{"31 days", 2, []string{"101"}, 31 * 24 * time.Hour, 2, 1, 31 * 288},
+	} {
+		c, ct, ids := newMeterServer(t, tc.n, tc.metered...)
+
+		// List the thermostats backwards, to check the order.
+		rev := make([]string, len(ids))
+		for i, id := range ids {
+			rev[len(ids)-1-i] = id
+		}
+		rs, err := c.GetMeterReport(strings.Join(rev, ","), start, start.Add(tc.span), nil)
+		if err != nil {
+			t.Errorf("%s: GetMeterReport: %v", tc.name, err)
+			continue
+		}
+		if n := ct.count("/1/meterReport"); n != tc.wantReports {
+			t.Errorf("%s: sent %d meter report requests, want %d", tc.name, n, tc.wantReports)
+		}
+		if n := ct.count("/1/thermostat"); n != tc.wantZones {
+			t.Errorf("%s: sent %d thermostat requests, want %d", tc.name, n, tc.wantZones)
+		}
+		if want := len(tc.metered) * tc.wantPerMetered; len(rs) != want {
+			t.Errorf("%s: got %d readings, want %d", tc.name, len(rs), want)
+			continue
+		}
+		for i, r := range rs {
+			// Metered thermostats in requested order, then by time.
+			m := tc.metered[len(tc.metered)-1-i/tc.wantPerMetered]
+			want := start.Add(time.Duration(i%tc.wantPerMetered) * ecobee.RuntimeInterval)
+			var v float64
+			fmt.Sscan(m, &v)
+			if r.Thermostat != m || r.Meter != ecobee.MeterEnergy || !r.Time.Equal(want) || r.Value != v/100 {
+				t.Errorf("%s: reading %d = %+v, want %s energy %v at %v", tc.name, i, r, m, v/100, want)
+				break
+			}
+		}
+	}
+}
+
+func TestGetMeterReportErrors(t *testing.T) {
+	c, _, _ := newMeterServer(t, 1, "100")
+	start := time.Date(2017, 12, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := c.GetMeterReport("100", start, start, nil); err == nil {
+		t.Error("GetMeterReport of an empty range succeeded")
+	}
+	if _, err := c.GetMeterReport("100,999", start, start.Add(time.Hour), nil); err == nil {
+		t.Error("GetMeterReport of an unknown thermostat succeeded")
+	}
+}
@@ -0,0 +1,114 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// HvacMode is the operating mode of the HVAC system.
+type HvacMode string
+
+const (
+	HvacModeAuto        HvacMode = "auto"
+	HvacModeAuxHeatOnly HvacMode = "auxHeatOnly"
+	HvacModeCool        HvacMode = "cool"
+	HvacModeHeat        HvacMode = "heat"
+	HvacModeOff         HvacMode = "off"
+)
+
+// HvacModes lists all modes known to the API.
+var HvacModes = []HvacMode{HvacModeAuto, HvacModeAuxHeatOnly, HvacModeCool, HvacModeHeat, HvacModeOff}
+
+// ParseHvacMode parses a mode name, ignoring case.
+func ParseHvacMode(s string) (HvacMode, error) {
+	for _, m := range HvacModes {
+		if strings.EqualFold(s, string(m)) {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown hvac mode %q", s)
+}
+
+// canHeat reports whether the thermostat has any heating equipment.
+func (s *Settings) canHeat() bool {
+	return s.HeatStages > 0 || s.HasHeatPump || s.HasBoiler
+}
+
+// canCool reports whether the thermostat has any cooling equipment.
+func (s *Settings) canCool() bool {
+	return s.CoolStages > 0
+}
+
+// HvacModes returns the modes supported by the equipment configured on
+// the thermostat.  "off" is always supported.
+func (s *Settings) HvacModes() []HvacMode {
+	var ms []HvacMode
+	for _, m := range HvacModes {
+		if s.SupportsHvacMode(m) {
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+// SupportsHvacMode reports whether the equipment configured on the
+// thermostat can run in mode m.  auxHeatOnly needs a heat pump with
+// auxiliary heat stages, and auto needs both heating and cooling and
+// the auto heat/cool feature to be enabled.
+func (s *Settings) SupportsHvacMode(m HvacMode) bool {
+	switch m {
+	case HvacModeOff:
+		return true
+	case HvacModeHeat:
+		return s.canHeat()
+	case HvacModeCool:
+		return s.canCool()
+	case HvacModeAuto:
+		return s.canHeat() && s.canCool() && s.AutoHeatCoolFeatureEnabled
+	case HvacModeAuxHeatOnly:
+		return s.HasHeatPump && s.HeatStages > 0
+	}
+	return false
+}
+
+// SetHvacMode changes the HVAC mode of a single thermostat after
+// checking that its equipment supports the mode.
+func (c *Client) SetHvacMode(thermostat string, mode HvacMode) error {
+	return c.SetHvacModeContext(context.Background(), thermostat, mode)
+}
+
+// SetHvacModeContext is like SetHvacMode, using ctx for the request.
+func (c *Client) SetHvacModeContext(ctx context.Context, thermostat string, mode HvacMode) error {
+	mode, err := ParseHvacMode(string(mode))
+	if err != nil {
+		return err
+	}
+	s, err := c.GetSettingsContext(ctx, thermostat)
+	if err != nil {
+		return err
+	}
+	if !s.SupportsHvacMode(mode) {
+		return fmt.Errorf("thermostat %s does not support hvac mode %q (supported: %v)", thermostat, mode, s.HvacModes())
+	}
+	if s.HvacMode == mode {
+		return nil
+	}
+	n := *s
+	n.HvacMode = mode
+	return c.UpdateSettingsContext(ctx, thermostat, *s, n)
+}
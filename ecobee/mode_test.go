@@ -0,0 +1,64 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSupportsHvacMode(t *testing.T) {
+	off := []HvacMode{HvacModeOff}
+	for _, tc := range []struct {
+		name     string
+		settings Settings
+		want     []HvacMode
+	}{
+		{"nothing", Settings{}, off},
+		{"forced air fan only", Settings{HasForcedAir: true}, off},
+		{"electric fan only", Settings{HasElectric: true}, off},
+		{"furnace", Settings{HasForcedAir: true, HeatStages: 2}, []HvacMode{HvacModeHeat, HvacModeOff}},
+		{"electric baseboard", Settings{HasElectric: true, HeatStages: 1}, []HvacMode{HvacModeHeat, HvacModeOff}},
+		{"boiler", Settings{HasBoiler: true}, []HvacMode{HvacModeHeat, HvacModeOff}},
+		{"air conditioner", Settings{HasForcedAir: true, CoolStages: 1}, []HvacMode{HvacModeCool, HvacModeOff}},
+		{"furnace and air conditioner", Settings{HasForcedAir: true, HeatStages: 1, CoolStages: 1},
+			[]HvacMode{HvacModeCool, HvacModeHeat, HvacModeOff}},
+		{"auto", Settings{HasForcedAir: true, HeatStages: 1, CoolStages: 1, AutoHeatCoolFeatureEnabled: true},
+			[]HvacMode{HvacModeAuto, HvacModeCool, HvacModeHeat, HvacModeOff}},
+		{"auto without cooling", Settings{HeatStages: 1, AutoHeatCoolFeatureEnabled: true}, []HvacMode{HvacModeHeat, HvacModeOff}},
+		{"heat pump", Settings{HasHeatPump: true, CoolStages: 1},
+			[]HvacMode{HvacModeCool, HvacModeHeat, HvacModeOff}},
+		{"heat pump with electric aux heat", Settings{HasHeatPump: true, HasElectric: true, HasForcedAir: true, HeatStages: 1, CoolStages: 1},
+			[]HvacMode{HvacModeAuxHeatOnly, HvacModeCool, HvacModeHeat, HvacModeOff}},
+		{"heat pump with aux heat and auto", Settings{HasHeatPump: true, HeatStages: 2, CoolStages: 1, AutoHeatCoolFeatureEnabled: true},
+			[]HvacMode{HvacModeAuto, HvacModeAuxHeatOnly, HvacModeCool, HvacModeHeat, HvacModeOff}},
+	} {
+		if got := tc.settings.HvacModes(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: HvacModes = %v, want %v", tc.name, got, tc.want)
+		}
+		for _, m := range HvacModes {
+			want := false
+			for _, w := range tc.want {
+				want = want || w == m
+			}
+			if got := tc.settings.SupportsHvacMode(m); got != want {
+				t.Errorf("%s: SupportsHvacMode(%s) = %v, want %v", tc.name, m, got, want)
+			}
+		}
+		if tc.settings.SupportsHvacMode("emergency") {
+			t.Errorf("%s: SupportsHvacMode(emergency) = true, want false", tc.name)
+		}
+	}
+}
@@ -18,67 +18,72 @@ package ecobee
 // https://docs.google.com/spreadsheets/d/1y9sjcvV_gTCG4UCVxVP2x6-LpmdunID9_oVMmhctRAI/view#gid=943586157
 // for how this file is generated.
 
+import "encoding/json"
+
 type Event struct {
-	Type                   string `json:"type"`
-	Name                   string `json:"name"`
-	Running                bool   `json:"running"`
-	StartDate              string `json:"startDate"`
-	StartTime              string `json:"startTime"`
-	EndDate                string `json:"endDate"`
-	EndTime                string `json:"endTime"`
-	IsOccupied             bool   `json:"isOccupied"`
-	IsCoolOff              bool   `json:"isCoolOff"`
-	IsHeatOff              bool   `json:"isHeatOff"`
-	CoolHoldTemp           int    `json:"coolHoldTemp"`
-	HeatHoldTemp           int    `json:"heatHoldTemp"`
-	Fan                    string `json:"fan"`
-	Vent                   string `json:"vent,omitempty"`
-	VentilatorMinOnTime    int    `json:"ventilatorMinOnTime,omitempty"`
-	IsOptional             bool   `json:"isOptional"`
-	IsTemperatureRelative  bool   `json:"isTemperatureRelative"`
-	CoolRelativeTemp       int    `json:"coolRelativeTemp"`
-	HeatRelativeTemp       int    `json:"heatRelativeTemp"`
-	IsTemperatureAbsolute  bool   `json:"isTemperatureAbsolute"`
-	DutyCyclePercentage    int    `json:"dutyCyclePercentage"`
-	FanMinOnTime           int    `json:"fanMinOnTime"`
-	OccupiedSensorActive   bool   `json:"occupiedSensorActive,omitempty"`
-	UnoccupiedSensorActive bool   `json:"unoccupiedSensorActive"`
-	DrRampUpTemp           int    `json:"drRampUpTemp"`
-	DrRampUpTime           int    `json:"drRampUpTime"`
-	LinkRef                string `json:"linkRef,omitempty"`
-	HoldClimateRef         string `json:"holdClimateRef,omitempty"`
+	Type                   string      `json:"type"`
+	Name                   string      `json:"name"`
+	Running                bool        `json:"running"`
+	StartDate              string      `json:"startDate"`
+	StartTime              string      `json:"startTime"`
+	EndDate                string      `json:"endDate"`
+	EndTime                string      `json:"endTime"`
+	IsOccupied             bool        `json:"isOccupied"`
+	IsCoolOff              bool        `json:"isCoolOff"`
+	IsHeatOff              bool        `json:"isHeatOff"`
+	CoolHoldTemp           Temperature `json:"coolHoldTemp"`
+	HeatHoldTemp           Temperature `json:"heatHoldTemp"`
+	Fan                    string      `json:"fan"`
+	Vent                   string      `json:"vent,omitempty"`
+	VentilatorMinOnTime    int         `json:"ventilatorMinOnTime,omitempty"`
+	IsOptional             bool        `json:"isOptional"`
+	IsTemperatureRelative  bool        `json:"isTemperatureRelative"`
+	CoolRelativeTemp       int         `json:"coolRelativeTemp"`
+	HeatRelativeTemp       int         `json:"heatRelativeTemp"`
+	IsTemperatureAbsolute  bool        `json:"isTemperatureAbsolute"`
+	DutyCyclePercentage    int         `json:"dutyCyclePercentage"`
+	FanMinOnTime           int         `json:"fanMinOnTime"`
+	OccupiedSensorActive   bool        `json:"occupiedSensorActive,omitempty"`
+	UnoccupiedSensorActive bool        `json:"unoccupiedSensorActive"`
+	DrRampUpTemp           int         `json:"drRampUpTemp"`
+	DrRampUpTime           int         `json:"drRampUpTime"`
+	LinkRef                string      `json:"linkRef,omitempty"`
+	HoldClimateRef         string      `json:"holdClimateRef,omitempty"`
 }
 
 type SetHoldParams struct {
 	Event
-	CoolHoldTemp   int    `json:"coolHoldTemp"`
-	HeatHoldTemp   int    `json:"heatHoldTemp"`
-	HoldClimateRef string `json:"holdClimateRef,omitempty"`
-	StartDate      string `json:"startDate,omitempty"`
-	StartTime      string `json:"startTime,omitempty"`
-	EndDate        string `json:"endDate,omitempty"`
-	EndTime        string `json:"endTime,omitempty"`
-	HoldType       string `json:"holdType,omitempty"`
-	HoldHours      int    `json:"holdHours,omitempty"`
+	CoolHoldTemp   Temperature `json:"coolHoldTemp"`
+	HeatHoldTemp   Temperature `json:"heatHoldTemp"`
+	HoldClimateRef string      `json:"holdClimateRef,omitempty"`
+	StartDate      string      `json:"startDate,omitempty"`
+	StartTime      string      `json:"startTime,omitempty"`
+	EndDate        string      `json:"endDate,omitempty"`
+	EndTime        string      `json:"endTime,omitempty"`
+	HoldType       string      `json:"holdType,omitempty"`
+	HoldHours      int         `json:"holdHours,omitempty"`
 }
 
+// Alert is an alert or reminder raised by a thermostat, or a message
+// sent to it.  Fields other than those used by sendMessage are omitted
+// when empty so that SendMessageParams stays minimal.
 type Alert struct {
-	//	AcknowledgeRef       string `json:"acknowledgeRef"`
-	//	Date                 string `json:"date"`
-	//	Time                 string `json:"time"`
-	//	Severity             string `json:"severity"`
-	Text string `json:"text"`
-	//	AlertNumber          int    `json:"alertNumber"`
-	AlertType       string `json:"alertType"`
-	IsOperatorAlert bool   `json:"isOperatorAlert"`
-	// Reminder             string `json:"reminder"`
-	// ShowIdt              bool   `json:"showIdt"`
-	// ShowWeb              bool   `json:"showWeb"`
-	// SendEmail            bool   `json:"sendEmail"`
-	// Acknowledgement      string `json:"acknowledgement"`
-	// RemindMeLater        bool   `json:"remindMeLater"`
-	// ThermostatIdentifier string `json:"thermostatIdentifier"`
-	// NotificationType     string `json:"notificationType"`
+	AcknowledgeRef       string `json:"acknowledgeRef,omitempty"`
+	Date                 string `json:"date,omitempty"`
+	Time                 string `json:"time,omitempty"`
+	Severity             string `json:"severity,omitempty"`
+	Text                 string `json:"text"`
+	AlertNumber          int    `json:"alertNumber,omitempty"`
+	AlertType            string `json:"alertType"`
+	IsOperatorAlert      bool   `json:"isOperatorAlert"`
+	Reminder             string `json:"reminder,omitempty"`
+	ShowIdt              bool   `json:"showIdt,omitempty"`
+	ShowWeb              bool   `json:"showWeb,omitempty"`
+	SendEmail            bool   `json:"sendEmail,omitempty"`
+	Acknowledgement      string `json:"acknowledgement,omitempty"`
+	RemindMeLater        bool   `json:"remindMeLater,omitempty"`
+	ThermostatIdentifier string `json:"thermostatIdentifier,omitempty"`
+	NotificationType     string `json:"notificationType,omitempty"`
 }
 
 type SendMessageParams struct {
@@ -119,8 +124,17 @@ type Function struct {
 }
 
 type UpdateThermostatRequest struct {
-	Selection Selection  `json:"selection"`
-	Functions []Function `json:"functions"`
+	Selection  Selection         `json:"selection"`
+	Thermostat *ThermostatUpdate `json:"thermostat,omitempty"`
+	Functions  []Function        `json:"functions,omitempty"`
+}
+
+// ThermostatUpdate is the subset of the thermostat object that can be
+// written with UpdateThermostat.  Only non-nil sections are sent, and
+// a section only changes the fields it contains.
+type ThermostatUpdate struct {
+	Settings map[string]interface{} `json:"settings,omitempty"`
+	Program  *Program               `json:"program,omitempty"`
 }
 
 type UpdateThermostatResponse struct {
@@ -137,21 +151,23 @@ type Status struct {
 }
 
 type Thermostat struct {
-	Identifier     string `json:"identifier"`
-	Name           string `json:"name"`
-	ThermostatRev  string `json:"thermostatRev"`
-	IsRegistered   bool   `json:"isRegistered"`
-	ModelNumber    string `json:"modelNumber"`
-	Brand          string `json:"brand"`
-	Features       string `json:"features"`
-	LastModified   string `json:"lastModified"`
-	ThermostatTime string `json:"thermostatTime"`
-	UtcTime        string `json:"utcTime"`
-	//Alerts         []Alert  `json:"alerts"`
-	//Settings       Settings `json:"settings"`
+	Identifier      string          `json:"identifier"`
+	Name            string          `json:"name"`
+	ThermostatRev   string          `json:"thermostatRev"`
+	IsRegistered    bool            `json:"isRegistered"`
+	ModelNumber     string          `json:"modelNumber"`
+	Brand           string          `json:"brand"`
+	Features        string          `json:"features"`
+	LastModified    string          `json:"lastModified"`
+	ThermostatTime  string          `json:"thermostatTime"`
+	UtcTime         string          `json:"utcTime"`
+	Alerts          []Alert         `json:"alerts"`
+	Settings        Settings        `json:"settings"`
 	Runtime         Runtime         `json:"runtime"`
 	ExtendedRuntime ExtendedRuntime `json:"extendedRuntime"`
 	/// ...
+	Location Location `json:"location"`
+	/// ...
 	Events  []Event `json:"events"`
 	Program Program `json:"program"`
 	/// ...
@@ -159,59 +175,188 @@ type Thermostat struct {
 	Weather       Weather        `json:"weather"`
 }
 
+type Settings struct {
+	HvacMode                            HvacMode    `json:"hvacMode"`
+	LastServiceDate                     string      `json:"lastServiceDate"`
+	ServiceRemindMe                     bool        `json:"serviceRemindMe"`
+	MonthsBetweenService                int         `json:"monthsBetweenService"`
+	RemindMeDate                        string      `json:"remindMeDate"`
+	Vent                                string      `json:"vent"`
+	VentilatorMinOnTime                 int         `json:"ventilatorMinOnTime"`
+	ServiceRemindTechnician             bool        `json:"serviceRemindTechnician"`
+	EiLocation                          string      `json:"eiLocation"`
+	ColdTempAlert                       Temperature `json:"coldTempAlert"`
+	ColdTempAlertEnabled                bool        `json:"coldTempAlertEnabled"`
+	HotTempAlert                        Temperature `json:"hotTempAlert"`
+	HotTempAlertEnabled                 bool        `json:"hotTempAlertEnabled"`
+	CoolStages                          int         `json:"coolStages"`
+	HeatStages                          int         `json:"heatStages"`
+	MaxSetBack                          int         `json:"maxSetBack"`
+	MaxSetForward                       int         `json:"maxSetForward"`
+	QuickSaveSetBack                    int         `json:"quickSaveSetBack"`
+	QuickSaveSetForward                 int         `json:"quickSaveSetForward"`
+	HasHeatPump                         bool        `json:"hasHeatPump"`
+	HasForcedAir                        bool        `json:"hasForcedAir"`
+	HasBoiler                           bool        `json:"hasBoiler"`
+	HasHumidifier                       bool        `json:"hasHumidifier"`
+	HasErv                              bool        `json:"hasErv"`
+	HasHrv                              bool        `json:"hasHrv"`
+	CondensationAvoid                   bool        `json:"condensationAvoid"`
+	UseCelsius                          bool        `json:"useCelsius"`
+	UseTimeFormat12                     bool        `json:"useTimeFormat12"`
+	Locale                              string      `json:"locale"`
+	Humidity                            string      `json:"humidity"`
+	HumidifierMode                      string      `json:"humidifierMode"`
+	BacklightOnIntensity                int         `json:"backlightOnIntensity"`
+	BacklightSleepIntensity             int         `json:"backlightSleepIntensity"`
+	BacklightOffTime                    int         `json:"backlightOffTime"`
+	SoundTickVolume                     int         `json:"soundTickVolume"`
+	SoundAlertVolume                    int         `json:"soundAlertVolume"`
+	CompressorProtectionMinTime         int         `json:"compressorProtectionMinTime"`
+	CompressorProtectionMinTemp         Temperature `json:"compressorProtectionMinTemp"`
+	Stage1HeatingDifferentialTemp       int         `json:"stage1HeatingDifferentialTemp"`
+	Stage1CoolingDifferentialTemp       int         `json:"stage1CoolingDifferentialTemp"`
+	Stage1HeatingDissipationTime        int         `json:"stage1HeatingDissipationTime"`
+	Stage1CoolingDissipationTime        int         `json:"stage1CoolingDissipationTime"`
+	HeatPumpReversalOnCool              bool        `json:"heatPumpReversalOnCool"`
+	FanControlRequired                  bool        `json:"fanControlRequired"`
+	FanMinOnTime                        int         `json:"fanMinOnTime"`
+	HeatCoolMinDelta                    int         `json:"heatCoolMinDelta"`
+	TempCorrection                      int         `json:"tempCorrection"`
+	HoldAction                          string      `json:"holdAction"`
+	HeatPumpGroundWater                 bool        `json:"heatPumpGroundWater"`
+	HasElectric                         bool        `json:"hasElectric"`
+	HasDehumidifier                     bool        `json:"hasDehumidifier"`
+	DehumidifierMode                    string      `json:"dehumidifierMode"`
+	DehumidifierLevel                   int         `json:"dehumidifierLevel"`
+	DehumidifyWithAC                    bool        `json:"dehumidifyWithAC"`
+	DehumidifyOvercoolOffset            int         `json:"dehumidifyOvercoolOffset"`
+	AutoHeatCoolFeatureEnabled          bool        `json:"autoHeatCoolFeatureEnabled"`
+	WifiOfflineAlert                    bool        `json:"wifiOfflineAlert"`
+	HeatMinTemp                         Temperature `json:"heatMinTemp"`
+	HeatMaxTemp                         Temperature `json:"heatMaxTemp"`
+	CoolMinTemp                         Temperature `json:"coolMinTemp"`
+	CoolMaxTemp                         Temperature `json:"coolMaxTemp"`
+	HeatRangeHigh                       Temperature `json:"heatRangeHigh"`
+	HeatRangeLow                        Temperature `json:"heatRangeLow"`
+	CoolRangeHigh                       Temperature `json:"coolRangeHigh"`
+	CoolRangeLow                        Temperature `json:"coolRangeLow"`
+	UserAccessCode                      string      `json:"userAccessCode"`
+	UserAccessSetting                   int         `json:"userAccessSetting"`
+	AuxRuntimeAlert                     int         `json:"auxRuntimeAlert"`
+	AuxOutdoorTempAlert                 Temperature `json:"auxOutdoorTempAlert"`
+	AuxMaxOutdoorTemp                   Temperature `json:"auxMaxOutdoorTemp"`
+	AuxRuntimeAlertNotify               bool        `json:"auxRuntimeAlertNotify"`
+	AuxOutdoorTempAlertNotify           bool        `json:"auxOutdoorTempAlertNotify"`
+	AuxRuntimeAlertNotifyTechnician     bool        `json:"auxRuntimeAlertNotifyTechnician"`
+	AuxOutdoorTempAlertNotifyTechnician bool        `json:"auxOutdoorTempAlertNotifyTechnician"`
+	DisablePreHeating                   bool        `json:"disablePreHeating"`
+	DisablePreCooling                   bool        `json:"disablePreCooling"`
+	InstallerCodeRequired               bool        `json:"installerCodeRequired"`
+	DrAccept                            string      `json:"drAccept"`
+	IsRentalProperty                    bool        `json:"isRentalProperty"`
+	UseZoneController                   bool        `json:"useZoneController"`
+	RandomStartDelayCool                int         `json:"randomStartDelayCool"`
+	RandomStartDelayHeat                int         `json:"randomStartDelayHeat"`
+	HumidityHighAlert                   int         `json:"humidityHighAlert"`
+	HumidityLowAlert                    int         `json:"humidityLowAlert"`
+	DisableHeatPumpAlerts               bool        `json:"disableHeatPumpAlerts"`
+	DisableAlertsOnIdt                  bool        `json:"disableAlertsOnIdt"`
+	HumidityAlertNotify                 bool        `json:"humidityAlertNotify"`
+	HumidityAlertNotifyTechnician       bool        `json:"humidityAlertNotifyTechnician"`
+	TempAlertNotify                     bool        `json:"tempAlertNotify"`
+	TempAlertNotifyTechnician           bool        `json:"tempAlertNotifyTechnician"`
+	MonthlyElectricityBillLimit         int         `json:"monthlyElectricityBillLimit"`
+	EnableElectricityBillAlert          bool        `json:"enableElectricityBillAlert"`
+	EnableProjectedElectricityBillAlert bool        `json:"enableProjectedElectricityBillAlert"`
+	ElectricityBillingDayOfMonth        int         `json:"electricityBillingDayOfMonth"`
+	ElectricityBillCycleMonths          int         `json:"electricityBillCycleMonths"`
+	ElectricityBillStartMonth           int         `json:"electricityBillStartMonth"`
+	VentilatorMinOnTimeHome             int         `json:"ventilatorMinOnTimeHome"`
+	VentilatorMinOnTimeAway             int         `json:"ventilatorMinOnTimeAway"`
+	BacklightOffDuringSleep             bool        `json:"backlightOffDuringSleep"`
+	AutoAway                            bool        `json:"autoAway"`
+	SmartCirculation                    bool        `json:"smartCirculation"`
+	FollowMeComfort                     bool        `json:"followMeComfort"`
+	VentilatorType                      string      `json:"ventilatorType"`
+	IsVentilatorTimerOn                 bool        `json:"isVentilatorTimerOn"`
+	VentilatorOffDateTime               string      `json:"ventilatorOffDateTime"`
+	HasUVFilter                         bool        `json:"hasUVFilter"`
+	CoolingLockout                      bool        `json:"coolingLockout"`
+	VentilatorFreeCooling               bool        `json:"ventilatorFreeCooling"`
+	DehumidifyWhenHeating               bool        `json:"dehumidifyWhenHeating"`
+	VentilatorDehumidify                bool        `json:"ventilatorDehumidify"`
+	GroupRef                            string      `json:"groupRef"`
+	GroupName                           string      `json:"groupName"`
+	GroupSetting                        int         `json:"groupSetting"`
+}
+
 type Runtime struct {
-	RuntimeRev         string `json:"runtimeRev"`
-	Connected          bool   `json:"connected"`
-	FirstConnected     string `json:"firstConnected"`
-	ConnectDateTime    string `json:"connectDateTime"`
-	DisconnectDateTime string `json:"disconnectDateTime"`
-	LastModified       string `json:"lastModified"`
-	LastStatusModified string `json:"lastStatusModified"`
-	RuntimeDate        string `json:"runtimeDate"`
-	RuntimeInterval    int    `json:"runtimeInterval"`
-	ActualTemperature  int    `json:"actualTemperature"`
-	ActualHumidity     int    `json:"actualHumidity"`
-	RawTemperature     int    `json:"rawTemperature"`
-	ShowIconMode       int    `json:"showIconMode"`
-	DesiredHeat        int    `json:"desiredHeat"`
-	DesiredCool        int    `json:"desiredCool"`
-	DesiredHumidity    int    `json:"desiredHumidity"`
-	DesiredDehumidity  int    `json:"desiredDehumidity"`
-	DesiredFanMode     string `json:"desiredFanMode"`
-	ActualVOC          int    `json:"actualVOC"`
-	ActualCO2          int    `json:"actualCO2"`
-	ActualAQAccuracy   int    `json:"actualAQAccuracy"`
-	ActualAQScore      int    `json:"actualAQScore"`
-	DesiredHeatRange   []int  `json:"desiredHeatRange"`
-	DesiredCoolRange   []int  `json:"desiredCoolRange"`
+	RuntimeRev         string        `json:"runtimeRev"`
+	Connected          bool          `json:"connected"`
+	FirstConnected     string        `json:"firstConnected"`
+	ConnectDateTime    string        `json:"connectDateTime"`
+	DisconnectDateTime string        `json:"disconnectDateTime"`
+	LastModified       string        `json:"lastModified"`
+	LastStatusModified string        `json:"lastStatusModified"`
+	RuntimeDate        string        `json:"runtimeDate"`
+	RuntimeInterval    int           `json:"runtimeInterval"`
+	ActualTemperature  Temperature   `json:"actualTemperature"`
+	ActualHumidity     int           `json:"actualHumidity"`
+	RawTemperature     Temperature   `json:"rawTemperature"`
+	ShowIconMode       int           `json:"showIconMode"`
+	DesiredHeat        Temperature   `json:"desiredHeat"`
+	DesiredCool        Temperature   `json:"desiredCool"`
+	DesiredHumidity    int           `json:"desiredHumidity"`
+	DesiredDehumidity  int           `json:"desiredDehumidity"`
+	DesiredFanMode     string        `json:"desiredFanMode"`
+	ActualVOC          int           `json:"actualVOC"`
+	ActualCO2          int           `json:"actualCO2"`
+	ActualAQAccuracy   int           `json:"actualAQAccuracy"`
+	ActualAQScore      int           `json:"actualAQScore"`
+	DesiredHeatRange   []Temperature `json:"desiredHeatRange"`
+	DesiredCoolRange   []Temperature `json:"desiredCoolRange"`
 }
 
 type ExtendedRuntime struct {
-	LastReadingTimestamp     string   `json:"lastReadingTimestamp"`
-	RuntimeDate              string   `json:"runtimeDate"`
-	RuntimeInterval          int      `json:"runtimeInterval"`
-	ActualTemperature        []int    `json:"actualTemperature"`
-	ActualHumidity           []int    `json:"actualHumidity"`
-	DesiredHeat              []int    `json:"desiredHeat"`
-	DesiredCool              []int    `json:"desiredCool"`
-	DesiredHumidity          []int    `json:"desiredHumidity"`
-	DesiredDehumidity        []int    `json:"desiredDehumidity"`
-	DmOffset                 []int    `json:"dmOffset"`
-	HvacMode                 []string `json:"hvacMode"`
-	HeatPump1                []int    `json:"heatPump1"`
-	HeatPump2                []int    `json:"heatPump2"`
-	AuxHeat1                 []int    `json:"auxHeat1"`
-	AuxHeat2                 []int    `json:"auxHeat2"`
-	AuxHeat3                 []int    `json:"auxHeat3"`
-	Cool1                    []int    `json:"cool1"`
-	Cool2                    []int    `json:"cool2"`
-	Fan                      []int    `json:"fan"`
-	Humidifier               []int    `json:"humidifier"`
-	Dehumidifier             []int    `json:"dehumidifier"`
-	Economizer               []int    `json:"economizer"`
-	Ventilator               []int    `json:"ventilator"`
-	CurrentElectricityBill   int      `json:"currentElectricityBill"`
-	ProjectedElectricityBill int      `json:"projectedElectricityBill"`
+	LastReadingTimestamp     string        `json:"lastReadingTimestamp"`
+	RuntimeDate              string        `json:"runtimeDate"`
+	RuntimeInterval          int           `json:"runtimeInterval"`
+	ActualTemperature        []Temperature `json:"actualTemperature"`
+	ActualHumidity           []int         `json:"actualHumidity"`
+	DesiredHeat              []Temperature `json:"desiredHeat"`
+	DesiredCool              []Temperature `json:"desiredCool"`
+	DesiredHumidity          []int         `json:"desiredHumidity"`
+	DesiredDehumidity        []int         `json:"desiredDehumidity"`
+	DmOffset                 []int         `json:"dmOffset"`
+	HvacMode                 []string      `json:"hvacMode"`
+	HeatPump1                []int         `json:"heatPump1"`
+	HeatPump2                []int         `json:"heatPump2"`
+	AuxHeat1                 []int         `json:"auxHeat1"`
+	AuxHeat2                 []int         `json:"auxHeat2"`
+	AuxHeat3                 []int         `json:"auxHeat3"`
+	Cool1                    []int         `json:"cool1"`
+	Cool2                    []int         `json:"cool2"`
+	Fan                      []int         `json:"fan"`
+	Humidifier               []int         `json:"humidifier"`
+	Dehumidifier             []int         `json:"dehumidifier"`
+	Economizer               []int         `json:"economizer"`
+	Ventilator               []int         `json:"ventilator"`
+	CurrentElectricityBill   int           `json:"currentElectricityBill"`
+	ProjectedElectricityBill int           `json:"projectedElectricityBill"`
+}
+
+type Location struct {
+	TimeZoneOffsetMinutes int    `json:"timeZoneOffsetMinutes"`
+	TimeZone              string `json:"timeZone"`
+	IsDaylightSaving      bool   `json:"isDaylightSaving"`
+	StreetAddress         string `json:"streetAddress"`
+	City                  string `json:"city"`
+	ProvinceState         string `json:"provinceState"`
+	Country               string `json:"country"`
+	PostalCode            string `json:"postalCode"`
+	PhoneNumber           string `json:"phoneNumber"`
+	MapCoordinates        string `json:"mapCoordinates"`
 }
 
 type GetThermostatsRequest struct {
@@ -235,10 +380,10 @@ type Page struct {
 type RemoteSensor struct {
 	ID         string                   `json:"id"`
 	Name       string                   `json:"name"`
-	Type       string                   `json:"type"`
-	Code       string                   `json:"code"`
-	InUse      bool                     `json:"inUse"`
-	Capability []RemoteSensorCapability `json:"capability"`
+	Type       string                   `json:"type,omitempty"`
+	Code       string                   `json:"code,omitempty"`
+	InUse      bool                     `json:"inUse,omitempty"`
+	Capability []RemoteSensorCapability `json:"capability,omitempty"`
 }
 
 type RemoteSensorCapability struct {
@@ -259,15 +404,19 @@ type Climate struct {
 	Owner               string         `json:"owner"`
 	Type                string         `json:"type"`
 	Colour              int            `json:"colour"`
-	CoolTemp            int            `json:"coolTemp"`
-	HeatTemp            int            `json:"heatTemp"`
+	CoolTemp            Temperature    `json:"coolTemp"`
+	HeatTemp            Temperature    `json:"heatTemp"`
 	Sensors             []RemoteSensor `json:"sensors"`
+
+	// extra holds fields not known to this package, so that they
+	// survive a read-modify-write of the program.
+	extra map[string]json.RawMessage
 }
 
 type Program struct {
 	Schedule          [][]string `json:"schedule"`
 	Climates          []Climate  `json:"climates"`
-	CurrentClimateRef string     `json:"currentClimateRef"`
+	CurrentClimateRef string     `json:"currentClimateRef,omitempty"` // read only
 }
 
 type GetThermostatSummaryRequest struct {
@@ -305,20 +454,20 @@ type Weather struct {
 }
 
 type WeatherForecast struct {
-	WeatherSymbol    int    `json:"weatherSymbol"`
-	DateTime         string `json:"dateTime"`
-	Condition        string `json:"condition"`
-	Temperature      int    `json:"temperature"`
-	Pressure         int    `json:"pressure"`
-	RelativeHumidity int    `json:"relativeHumidity"`
-	Dewpoint         int    `json:"dewpoint"`
-	Visibility       int    `json:"visibility"`
-	WindSpeed        int    `json:"windSpeed"`
-	WindGust         int    `json:"windGust"`
-	WindDirection    string `json:"windDirection"`
-	WindBearing      int    `json:"windBearing"`
-	Pop              int    `json:"pop"`
-	TempHigh         int    `json:"tempHigh"`
-	TempLow          int    `json:"tempLow"`
-	Sky              int    `json:"sky"`
+	WeatherSymbol    int         `json:"weatherSymbol"`
+	DateTime         string      `json:"dateTime"`
+	Condition        string      `json:"condition"`
+	Temperature      Temperature `json:"temperature"`
+	Pressure         int         `json:"pressure"`
+	RelativeHumidity int         `json:"relativeHumidity"`
+	Dewpoint         Temperature `json:"dewpoint"`
+	Visibility       int         `json:"visibility"`
+	WindSpeed        int         `json:"windSpeed"`
+	WindGust         int         `json:"windGust"`
+	WindDirection    string      `json:"windDirection"`
+	WindBearing      int         `json:"windBearing"`
+	Pop              int         `json:"pop"`
+	TempHigh         Temperature `json:"tempHigh"`
+	TempLow          Temperature `json:"tempLow"`
+	Sky              int         `json:"sky"`
 }
@@ -1,135 +1,312 @@
 package ecobee
 
-type SelectionOption func(s Selection)
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultBaseURL is the root of the production ecobee API.
+const DefaultBaseURL = "https://api.ecobee.com"
+
+type clientOptions struct {
+	baseURL   string
+	client    *http.Client
+	transport http.RoundTripper
+	userAgent string
+	retry     RetryPolicy
+	limiter   *rateLimiter
+}
+
+// ClientOption configures a Client created by NewClientWithOptions.
+type ClientOption func(co *clientOptions)
+
+func newClientOptions(opts []ClientOption) *clientOptions {
+	co := &clientOptions{baseURL: DefaultBaseURL}
+	for _, o := range opts {
+		o(co)
+	}
+	return co
+}
+
+// httpClient returns the *http.Client described by the options.  The
+// caller's client is copied, never modified.
+func (co *clientOptions) httpClient() *http.Client {
+	hc := &http.Client{}
+	if co.client != nil {
+		*hc = *co.client
+	}
+	if co.transport != nil {
+		hc.Transport = co.transport
+	}
+	if co.userAgent != "" {
+		hc.Transport = &userAgentTransport{userAgent: co.userAgent, base: hc.Transport}
+	}
+	return hc
+}
+
+// WithBaseURL points the client at a different API root, such as a
+// local stand-in for the ecobee API.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(co *clientOptions) {
+		co.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient uses hc for all requests, including authorization.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(co *clientOptions) {
+		co.client = hc
+	}
+}
+
+// WithTransport uses rt as the transport for all requests.  It takes
+// precedence over the transport of a client set with WithHTTPClient.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(co *clientOptions) {
+		co.transport = rt
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) ClientOption {
+	return func(co *clientOptions) {
+		co.userAgent = ua
+	}
+}
+
+type userAgentTransport struct {
+	userAgent string
+	base      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// RoundTrippers must not modify the request.
+	r := req.Clone(req.Context())
+	r.Header.Set("User-Agent", t.userAgent)
+	return base.RoundTrip(r)
+}
+
+// Selection types.
+const (
+	SelectionTypeRegistered    = "registered"
+	SelectionTypeThermostats   = "thermostats"
+	SelectionTypeManagementSet = "managementSet"
+)
+
+// maxSelectionThermostats is the most thermostats ecobee allows in a
+// single "thermostats" selection.
+const maxSelectionThermostats = 25
+
+// SelectionOption modifies a Selection.  Options are applied in order,
+// so later options override earlier ones.
+type SelectionOption func(s *Selection)
 
 func WithIncludeAlerts(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeAlerts = value
 	}
 }
 
 func WithIncludeAudio(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeAudio = value
 	}
 }
 
 func WithIncludeDevice(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeDevice = value
 	}
 }
 
 func WithIncludeElectricity(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeElectricity = value
 	}
 }
 
 func WithIncludeEquipmentStatus(value bool) SelectionOption {
-	return func(s Selection) {
-		s.IncludeElectricity = value
+	return func(s *Selection) {
+		s.IncludeEquipmentStatus = value
 	}
 }
 
 func WithIncludeEvents(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeEvents = value
 	}
 }
 
 func WithIncludeExtendedRuntime(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeExtendedRuntime = value
 	}
 }
 
 func WithIncludeHouseDetails(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeHouseDetails = value
 	}
 }
 
 func WithIncludeLocation(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeLocation = value
 	}
 }
 
 func WithIncludeManagement(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeManagement = value
 	}
 }
 
 func WithIncludeNotificationSettings(value bool) SelectionOption {
-	return func(s Selection) {
-		s.IncludeManagement = value
+	return func(s *Selection) {
+		s.IncludeNotificationSettings = value
 	}
 }
 
 func WithIncludeOemCfg(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeOemCfg = value
 	}
 }
 
 func WithIncludePrivacy(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludePrivacy = value
 	}
 }
 
 func WithIncludeProgram(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeProgram = value
 	}
 }
 
 func WithIncludeRuntime(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeRuntime = value
 	}
 }
 
 func WithIncludeSecuritySettings(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeSecuritySettings = value
 	}
 }
 
 func WithIncludeSensors(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeSensors = value
 	}
 }
 
 func WithIncludeSettings(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeSettings = value
 	}
 }
 
 func WithIncludeTechnician(value bool) SelectionOption {
-	return func(s Selection) {
-		s.IncludeSettings = value
+	return func(s *Selection) {
+		s.IncludeTechnician = value
 	}
 }
 
 func WithIncludeUtility(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeUtility = value
 	}
 }
 
 func WithIncludeVersion(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeVersion = value
 	}
 }
 
 func WithIncludeWeather(value bool) SelectionOption {
-	return func(s Selection) {
+	return func(s *Selection) {
 		s.IncludeWeather = value
 	}
 }
+
+// WithThermostats selects the thermostats with the given identifiers.
+func WithThermostats(ids ...string) SelectionOption {
+	return func(s *Selection) {
+		s.SelectionType = SelectionTypeThermostats
+		s.SelectionMatch = strings.Join(ids, ",")
+	}
+}
+
+// WithRegistered selects every thermostat registered to the
+// authenticated user.
+func WithRegistered() SelectionOption {
+	return func(s *Selection) {
+		s.SelectionType = SelectionTypeRegistered
+		s.SelectionMatch = ""
+	}
+}
+
+// WithManagementSet selects the thermostats in a management set, for
+// EMS and utility accounts.  path is the set's path in the hierarchy,
+// e.g. "/Toronto/Campus".
+func WithManagementSet(path string) SelectionOption {
+	return func(s *Selection) {
+		s.SelectionType = SelectionTypeManagementSet
+		s.SelectionMatch = path
+	}
+}
+
+// NewSelection builds a Selection from opts and validates it.
+func NewSelection(opts ...SelectionOption) (Selection, error) {
+	var s Selection
+	s.Apply(opts...)
+	return s, s.Validate()
+}
+
+// Apply applies opts to s.
+func (s *Selection) Apply(opts ...SelectionOption) {
+	for _, o := range opts {
+		o(s)
+	}
+}
+
+// Validate checks that the selection type and match are consistent.
+func (s Selection) Validate() error {
+	switch s.SelectionType {
+	case SelectionTypeRegistered:
+		if s.SelectionMatch != "" {
+			return fmt.Errorf("selection type %q takes no match, got %q", s.SelectionType, s.SelectionMatch)
+		}
+	case SelectionTypeThermostats:
+		if s.SelectionMatch == "" {
+			return fmt.Errorf("selection type %q requires at least one thermostat identifier", s.SelectionType)
+		}
+		ids := strings.Split(s.SelectionMatch, ",")
+		if len(ids) > maxSelectionThermostats {
+			return fmt.Errorf("selection matches %d thermostats, limit is %d", len(ids), maxSelectionThermostats)
+		}
+		for _, id := range ids {
+			if id == "" {
+				return fmt.Errorf("empty thermostat identifier in selection match %q", s.SelectionMatch)
+			}
+		}
+	case SelectionTypeManagementSet:
+		if !strings.HasPrefix(s.SelectionMatch, "/") {
+			return fmt.Errorf("management set path must start with /, got %q", s.SelectionMatch)
+		}
+	case "":
+		return fmt.Errorf("missing selection type")
+	default:
+		return fmt.Errorf("unknown selection type %q", s.SelectionType)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+var includeOptions = []struct {
+	field string
+	opt   func(bool) ecobee.SelectionOption
+}{
+	{"IncludeAlerts", ecobee.WithIncludeAlerts},
+	{"IncludeAudio", ecobee.WithIncludeAudio},
+	{"IncludeDevice", ecobee.WithIncludeDevice},
+	{"IncludeElectricity", ecobee.WithIncludeElectricity},
+	{"IncludeEquipmentStatus", ecobee.WithIncludeEquipmentStatus},
+	{"IncludeEvents", ecobee.WithIncludeEvents},
+	{"IncludeExtendedRuntime", ecobee.WithIncludeExtendedRuntime},
+	{"IncludeHouseDetails", ecobee.WithIncludeHouseDetails},
+	{"IncludeLocation", ecobee.WithIncludeLocation},
+	{"IncludeManagement", ecobee.WithIncludeManagement},
+	{"IncludeNotificationSettings", ecobee.WithIncludeNotificationSettings},
+	{"IncludeOemCfg", ecobee.WithIncludeOemCfg},
+	{"IncludePrivacy", ecobee.WithIncludePrivacy},
+	{"IncludeProgram", ecobee.WithIncludeProgram},
+	{"IncludeRuntime", ecobee.WithIncludeRuntime},
+	{"IncludeSecuritySettings", ecobee.WithIncludeSecuritySettings},
+	{"IncludeSensors", ecobee.WithIncludeSensors},
+	{"IncludeSettings", ecobee.WithIncludeSettings},
+	{"IncludeTechnician", ecobee.WithIncludeTechnician},
+	{"IncludeUtility", ecobee.WithIncludeUtility},
+	{"IncludeVersion", ecobee.WithIncludeVersion},
+	{"IncludeWeather", ecobee.WithIncludeWeather},
+}
+
+// includeFields returns the names of the Include fields of s that are
+// set to v.
+func includeFields(s ecobee.Selection, v bool) []string {
+	var fields []string
+	rv := reflect.ValueOf(s)
+	for i := 0; i < rv.NumField(); i++ {
+		name := rv.Type().Field(i).Name
+		if strings.HasPrefix(name, "Include") && rv.Field(i).Bool() == v {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+func TestIncludeOptions(t *testing.T) {
+	covered := map[string]bool{}
+	for _, tc := range includeOptions {
+		covered[tc.field] = true
+
+		var s ecobee.Selection
+		s.Apply(tc.opt(true))
+		if got := includeFields(s, true); !reflect.DeepEqual(got, []string{tc.field}) {
+			t.Errorf("With%s(true) set %v, want only %s", tc.field, got, tc.field)
+		}
+
+		// Clearing one field leaves the others alone.
+		var all []ecobee.SelectionOption
+		for _, o := range includeOptions {
+			all = append(all, o.opt(true))
+		}
+		s = ecobee.Selection{}
+		s.Apply(append(all, tc.opt(false))...)
+		if got := includeFields(s, false); !reflect.DeepEqual(got, []string{tc.field}) {
+			t.Errorf("With%s(false) cleared %v, want only %s", tc.field, got, tc.field)
+		}
+	}
+	for _, f := range includeFields(ecobee.Selection{}, false) {
+		if !covered[f] {
+			t.Errorf("no option tested for Selection.%s", f)
+		}
+	}
+}
+
+func TestApplyOrder(t *testing.T) {
+	s := ecobee.Selection{IncludeRuntime: true}
+	s.Apply(ecobee.WithIncludeRuntime(false), ecobee.WithIncludeRuntime(true), ecobee.WithIncludeProgram(true))
+	if !s.IncludeRuntime || !s.IncludeProgram {
+		t.Errorf("Apply = %+v, want runtime and program included", s)
+	}
+}
+
+func TestNewSelection(t *testing.T) {
+	ids := func(n int) []string {
+		var ids []string
+		for i := 0; i < n; i++ {
+			ids = append(ids, fmt.Sprint(100+i))
+		}
+		return ids
+	}
+	for _, tc := range []struct {
+		name      string
+		opts      []ecobee.SelectionOption
+		wantType  string
+		wantMatch string
+		wantErr   bool
+	}{
+		{
+			name:      "thermostats",
+			opts:      []ecobee.SelectionOption{ecobee.WithThermostats("123", "456")},
+			wantType:  ecobee.SelectionTypeThermostats,
+			wantMatch: "123,456",
+		},
+		{
+			name:      "25 thermostats",
+			opts:      []ecobee.SelectionOption{ecobee.WithThermostats(ids(25)...)},
+			wantType:  ecobee.SelectionTypeThermostats,
+			wantMatch: strings.Join(ids(25), ","),
+		},
+		{
+			name:    "26 thermostats",
+			opts:    []ecobee.SelectionOption{ecobee.WithThermostats(ids(26)...)},
+			wantErr: true,
+		},
+		{
+			name:    "no thermostats",
+			opts:    []ecobee.SelectionOption{ecobee.WithThermostats()},
+			wantErr: true,
+		},
+		{
+			name:    "empty thermostat",
+			opts:    []ecobee.SelectionOption{ecobee.WithThermostats("123", "")},
+			wantErr: true,
+		},
+		{
+			name:     "registered",
+			opts:     []ecobee.SelectionOption{ecobee.WithRegistered()},
+			wantType: ecobee.SelectionTypeRegistered,
+		},
+		{
+			name:     "registered replaces thermostats",
+			opts:     []ecobee.SelectionOption{ecobee.WithThermostats("123"), ecobee.WithRegistered()},
+			wantType: ecobee.SelectionTypeRegistered,
+		},
+		{
+			name:      "management set",
+			opts:      []ecobee.SelectionOption{ecobee.WithManagementSet("/Toronto/Campus")},
+			wantType:  ecobee.SelectionTypeManagementSet,
+			wantMatch: "/Toronto/Campus",
+		},
+		{
+			name:    "management set without /",
+			opts:    []ecobee.SelectionOption{ecobee.WithManagementSet("Toronto")},
+			wantErr: true,
+		},
+		{
+			name:    "no type",
+			opts:    []ecobee.SelectionOption{ecobee.WithIncludeRuntime(true)},
+			wantErr: true,
+		},
+	} {
+		s, err := ecobee.NewSelection(tc.opts...)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: NewSelection = %+v, want error", tc.name, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: NewSelection: %v", tc.name, err)
+			continue
+		}
+		if s.SelectionType != tc.wantType || s.SelectionMatch != tc.wantMatch {
+			t.Errorf("%s: NewSelection = %q %q, want %q %q", tc.name, s.SelectionType, s.SelectionMatch, tc.wantType, tc.wantMatch)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		s       ecobee.Selection
+		wantErr bool
+	}{
+		{ecobee.Selection{SelectionType: "registered"}, false},
+		{ecobee.Selection{SelectionType: "registered", SelectionMatch: "123"}, true},
+		{ecobee.Selection{SelectionType: "thermostats", SelectionMatch: "123"}, false},
+		{ecobee.Selection{SelectionType: "thermostats", SelectionMatch: "123,,456"}, true},
+		{ecobee.Selection{SelectionType: "managementSet", SelectionMatch: "/"}, false},
+		{ecobee.Selection{SelectionType: "managementSet", SelectionMatch: ""}, true},
+		{ecobee.Selection{SelectionType: "bogus"}, true},
+	} {
+		if err := tc.s.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%+v.Validate() = %v, want error %v", tc.s, err, tc.wantErr)
+		}
+	}
+}
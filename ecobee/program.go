@@ -0,0 +1,333 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Climate returns the climate with climateRef ref, or nil.
+func (p *Program) Climate(ref string) *Climate {
+	for i := range p.Climates {
+		if p.Climates[i].ClimateRef == ref {
+			return &p.Climates[i]
+		}
+	}
+	return nil
+}
+
+// FindClimate returns the climate whose climateRef or name is s,
+// ignoring case, or nil.  Built in climates have refs like "away";
+// custom ones have generated refs like "smart1", and are easier to
+// find by name.
+func (p *Program) FindClimate(s string) *Climate {
+	if c := p.Climate(s); c != nil {
+		return c
+	}
+	for i := range p.Climates {
+		c := &p.Climates[i]
+		if strings.EqualFold(c.ClimateRef, s) || strings.EqualFold(c.Name, s) {
+			return c
+		}
+	}
+	return nil
+}
+
+// ClimateRefs returns the refs of all climates.
+func (p *Program) ClimateRefs() []string {
+	var refs []string
+	for _, c := range p.Climates {
+		refs = append(refs, c.ClimateRef)
+	}
+	return refs
+}
+
+// The schedule has a row of half hour slots for each day of the week,
+// starting on Monday.
+const (
+	SlotsPerDay  = 48
+	SlotDuration = 30 * time.Minute
+)
+
+// scheduleDay returns the index of d in Program.Schedule.
+func scheduleDay(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
+
+// Weekdays lists the days of the week in schedule order.
+var Weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// Validate checks that the schedule covers the whole week, and that
+// every slot refers to one of the climates.  New climates, which have
+// no climateRef until ecobee assigns one, need a name.
+func (p *Program) Validate() error {
+	if len(p.Schedule) != len(Weekdays) {
+		return fmt.Errorf("schedule has %d days, want %d", len(p.Schedule), len(Weekdays))
+	}
+	refs := map[string]bool{}
+	for _, c := range p.Climates {
+		if c.ClimateRef == "" {
+			if c.Name == "" {
+				return fmt.Errorf("new climate has no name")
+			}
+			continue
+		}
+		if refs[c.ClimateRef] {
+			return fmt.Errorf("duplicate climateRef %q", c.ClimateRef)
+		}
+		refs[c.ClimateRef] = true
+	}
+	for i, day := range p.Schedule {
+		if len(day) != SlotsPerDay {
+			return fmt.Errorf("%s has %d slots, want %d", Weekdays[i], len(day), SlotsPerDay)
+		}
+		for j, ref := range day {
+			if !refs[ref] {
+				return fmt.Errorf("%s %s: unknown climate %q", Weekdays[i], FormatClock(time.Duration(j)*SlotDuration), ref)
+			}
+		}
+	}
+	return nil
+}
+
+// Slot returns the climate ref scheduled for day d at time of day
+// tod, or "" if the schedule doesn't cover it.
+func (p *Program) Slot(d time.Weekday, tod time.Duration) string {
+	i, j := scheduleDay(d), int(tod/SlotDuration)
+	if i >= len(p.Schedule) || j < 0 || j >= len(p.Schedule[i]) {
+		return ""
+	}
+	return p.Schedule[i][j]
+}
+
+// ScheduleRange is a run of consecutive slots in a day with the same
+// climate.  Start and End are times of day; End is exclusive.
+type ScheduleRange struct {
+	Start, End time.Duration
+	ClimateRef string
+}
+
+func (r ScheduleRange) String() string {
+	return FormatClock(r.Start) + "-" + FormatClock(r.End) + " " + r.ClimateRef
+}
+
+// DayRanges returns the schedule of day d as ranges.
+func (p *Program) DayRanges(d time.Weekday) []ScheduleRange {
+	i := scheduleDay(d)
+	if i >= len(p.Schedule) {
+		return nil
+	}
+	var rs []ScheduleRange
+	for j, ref := range p.Schedule[i] {
+		tod := time.Duration(j) * SlotDuration
+		if n := len(rs); n > 0 && rs[n-1].ClimateRef == ref {
+			rs[n-1].End = tod + SlotDuration
+			continue
+		}
+		rs = append(rs, ScheduleRange{Start: tod, End: tod + SlotDuration, ClimateRef: ref})
+	}
+	return rs
+}
+
+// ScheduleChange replaces part of the schedule, e.g. weekdays from
+// 06:00 to 08:30 with the "home" climate.
+type ScheduleChange struct {
+	Days []time.Weekday
+	ScheduleRange
+}
+
+// SetSchedule applies ch to the schedule.  The range must fall on slot
+// boundaries, and the climate must exist.  An empty schedule is
+// created if needed.
+func (p *Program) SetSchedule(ch ScheduleChange) error {
+	if p.Climate(ch.ClimateRef) == nil {
+		return fmt.Errorf("unknown climate %q (known: %v)", ch.ClimateRef, p.ClimateRefs())
+	}
+	if ch.Start%SlotDuration != 0 || ch.End%SlotDuration != 0 {
+		return fmt.Errorf("schedule times must be on the hour or half hour, got %s", ch.ScheduleRange)
+	}
+	if ch.Start < 0 || ch.End > SlotsPerDay*SlotDuration || ch.Start >= ch.End {
+		return fmt.Errorf("invalid schedule range %s", ch.ScheduleRange)
+	}
+	if len(ch.Days) == 0 {
+		return fmt.Errorf("schedule change has no days")
+	}
+	for len(p.Schedule) < len(Weekdays) {
+		p.Schedule = append(p.Schedule, nil)
+	}
+	for _, d := range ch.Days {
+		i := scheduleDay(d)
+		for len(p.Schedule[i]) < SlotsPerDay {
+			p.Schedule[i] = append(p.Schedule[i], ch.ClimateRef)
+		}
+		for j := int(ch.Start / SlotDuration); j < int(ch.End/SlotDuration); j++ {
+			p.Schedule[i][j] = ch.ClimateRef
+		}
+	}
+	return nil
+}
+
+// dayNames maps the names accepted by ParseDays to days.
+var dayNames = map[string][]time.Weekday{
+	"mon":      {time.Monday},
+	"tue":      {time.Tuesday},
+	"wed":      {time.Wednesday},
+	"thu":      {time.Thursday},
+	"fri":      {time.Friday},
+	"sat":      {time.Saturday},
+	"sun":      {time.Sunday},
+	"weekdays": Weekdays[:5],
+	"weekends": Weekdays[5:],
+	"daily":    Weekdays,
+	"all":      Weekdays,
+}
+
+// ParseDays parses a comma separated list of days: three letter day
+// names, ranges of them like "mon-thu", "weekdays", "weekends" or
+// "daily".
+func ParseDays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	seen := map[time.Weekday]bool{}
+	add := func(ds ...time.Weekday) {
+		for _, d := range ds {
+			if !seen[d] {
+				seen[d] = true
+				days = append(days, d)
+			}
+		}
+	}
+	for _, f := range strings.Split(strings.ToLower(s), ",") {
+		f = strings.TrimSpace(f)
+		if from, to, ok := strings.Cut(f, "-"); ok {
+			a, aok := dayNames[from]
+			b, bok := dayNames[to]
+			if !aok || !bok || len(a) != 1 || len(b) != 1 {
+				return nil, fmt.Errorf("invalid day range %q", f)
+			}
+			for i := scheduleDay(a[0]); ; i = (i + 1) % 7 {
+				add(Weekdays[i])
+				if Weekdays[i] == b[0] {
+					break
+				}
+			}
+			continue
+		}
+		ds, ok := dayNames[f]
+		if !ok {
+			return nil, fmt.Errorf("unknown day %q", f)
+		}
+		add(ds...)
+	}
+	return days, nil
+}
+
+// ParseClock parses a time of day such as "06:30".  "24:00" is the end
+// of the day.
+func ParseClock(s string) (time.Duration, error) {
+	h, m, ok := strings.Cut(s, ":")
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || hh < 0 || mm < 0 || mm > 59 || hh*60+mm > 24*60 {
+		return 0, fmt.Errorf("invalid time of day %q, want HH:MM", s)
+	}
+	return time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute, nil
+}
+
+// FormatClock formats a time of day as "HH:MM".
+func FormatClock(tod time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(tod/time.Hour), int(tod%time.Hour/time.Minute))
+}
+
+// ParseScheduleChange parses a schedule change such as
+// "weekdays 06:00-08:30 home".  The climate may be given by ref or name
+// and is resolved against p.
+func (p *Program) ParseScheduleChange(s string) (ScheduleChange, error) {
+	f := strings.Fields(s)
+	if len(f) < 3 {
+		return ScheduleChange{}, fmt.Errorf("invalid schedule change %q, want DAYS HH:MM-HH:MM CLIMATE", s)
+	}
+	var ch ScheduleChange
+	var err error
+	if ch.Days, err = ParseDays(f[0]); err != nil {
+		return ScheduleChange{}, err
+	}
+	from, to, ok := strings.Cut(f[1], "-")
+	if !ok {
+		return ScheduleChange{}, fmt.Errorf("invalid time range %q, want HH:MM-HH:MM", f[1])
+	}
+	if ch.Start, err = ParseClock(from); err != nil {
+		return ScheduleChange{}, err
+	}
+	if ch.End, err = ParseClock(to); err != nil {
+		return ScheduleChange{}, err
+	}
+	name := strings.Join(f[2:], " ")
+	c := p.FindClimate(name)
+	if c == nil {
+		return ScheduleChange{}, fmt.Errorf("unknown climate %q (known: %v)", name, p.ClimateRefs())
+	}
+	ch.ClimateRef = c.ClimateRef
+	return ch, nil
+}
+
+// GetProgram fetches the program of a single thermostat.
+func (c *Client) GetProgram(thermostat string) (*Program, error) {
+	return c.GetProgramContext(context.Background(), thermostat)
+}
+
+// GetProgramContext is like GetProgram, using ctx for the request.
+func (c *Client) GetProgramContext(ctx context.Context, thermostat string) (*Program, error) {
+	ts, err := c.GetThermostatsContext(ctx, Selection{
+		SelectionType:  SelectionTypeThermostats,
+		SelectionMatch: thermostat,
+		IncludeProgram: true,
+	})
+	if err != nil {
+		return nil, err
+	} else if len(ts) != 1 {
+		return nil, fmt.Errorf("got %d thermostats, wanted 1", len(ts))
+	}
+	return &ts[0].Program, nil
+}
+
+// UpdateProgram replaces the schedule and climates of the thermostats
+// matched by thermostat with those of p, after validating it.
+func (c *Client) UpdateProgram(thermostat string, p Program) error {
+	return c.UpdateProgramContext(context.Background(), thermostat, p)
+}
+
+// UpdateProgramContext is like UpdateProgram, using ctx for the
+// request.
+func (c *Client) UpdateProgramContext(ctx context.Context, thermostat string, p Program) error {
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("invalid program: %w", err)
+	}
+	// The current climate follows from the schedule and can't be set.
+	p.CurrentClimateRef = ""
+	r := &UpdateThermostatRequest{
+		Selection: Selection{
+			SelectionType:  SelectionTypeThermostats,
+			SelectionMatch: thermostat,
+		},
+		Thermostat: &ThermostatUpdate{
+			Program: &p,
+		},
+	}
+	return c.UpdateThermostatContext(ctx, *r)
+}
@@ -0,0 +1,144 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+// testProgram returns the program of testThermostat, with a custom
+// climate named "Night Owl".
+func testProgram() ecobee.Program {
+	p := testThermostat("123", "Home").Program
+	p.Climates = append(p.Climates, ecobee.Climate{Name: "Night Owl", ClimateRef: "smart1", HeatTemp: 650, CoolTemp: 780})
+	return p
+}
+
+func TestParseScheduleChange(t *testing.T) {
+	mon, tue, wed, thu, fri, sat, sun := time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday
+	p := testProgram()
+	for _, tc := range []struct {
+		s          string
+		days       []time.Weekday
+		start, end time.Duration
+		ref        string
+		wantErr    bool
+	}{
+		{s: "weekdays 06:00-08:30 home", days: []time.Weekday{mon, tue, wed, thu, fri}, start: 6 * time.Hour, end: 8*time.Hour + 30*time.Minute, ref: "home"},
+		{s: "fri-mon 00:00-24:00 Away", days: []time.Weekday{fri, sat, sun, mon}, end: 24 * time.Hour, ref: "away"},
+		{s: "sun-sun 22:00-24:00 night owl", days: []time.Weekday{sun}, start: 22 * time.Hour, end: 24 * time.Hour, ref: "smart1"},
+		{s: "Mon,wed 23:30-24:00 AWAY", days: []time.Weekday{mon, wed}, start: 23*time.Hour + 30*time.Minute, end: 24 * time.Hour, ref: "away"},
+		{s: "weekends,sat,daily 07:00-09:00 home", days: []time.Weekday{sat, sun, mon, tue, wed, thu, fri}, start: 7 * time.Hour, end: 9 * time.Hour, ref: "home"},
+		{s: "mon 06:00-07:00", wantErr: true},
+		{s: "mon 06:00 home", wantErr: true},
+		{s: "mon 06:00-24:30 home", wantErr: true},
+		{s: "mon 06:00-7 home", wantErr: true},
+		{s: "funday 06:00-07:00 home", wantErr: true},
+		{s: "mon-weekdays 06:00-07:00 home", wantErr: true},
+		{s: "mon 06:00-07:00 sleep", wantErr: true},
+	} {
+		ch, err := p.ParseScheduleChange(tc.s)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseScheduleChange(%q) = %+v, want error", tc.s, ch)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseScheduleChange(%q): %v", tc.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(ch.Days, tc.days) || ch.Start != tc.start || ch.End != tc.end || ch.ClimateRef != tc.ref {
+			t.Errorf("ParseScheduleChange(%q) = %v %s, want %v %s", tc.s, ch.Days, ch.ScheduleRange,
+				tc.days, ecobee.ScheduleRange{Start: tc.start, End: tc.end, ClimateRef: tc.ref})
+		}
+	}
+}
+
+func TestSetSchedule(t *testing.T) {
+	for _, tc := range []struct {
+		s       string
+		days    []int // rows changed
+		from    int   // first slot changed
+		to      int   // slot after the last changed
+		wantErr bool
+	}{
+		{s: "mon 00:00-00:30 away", days: []int{0}, from: 0, to: 1},
+		{s: "sun 23:30-24:00 away", days: []int{6}, from: 47, to: 48},
+		{s: "fri-mon 00:00-24:00 away", days: []int{4, 5, 6, 0}, from: 0, to: 48},
+		{s: "weekdays 06:00-08:30 night owl", days: []int{0, 1, 2, 3, 4}, from: 12, to: 17},
+		{s: "mon 06:15-08:00 away", wantErr: true},
+		{s: "mon 22:00-00:00 away", wantErr: true},
+		{s: "mon 08:00-08:00 away", wantErr: true},
+	} {
+		p := testProgram()
+		ch, err := p.ParseScheduleChange(tc.s)
+		if err != nil {
+			t.Errorf("ParseScheduleChange(%q): %v", tc.s, err)
+			continue
+		}
+		err = p.SetSchedule(ch)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("SetSchedule(%q) succeeded, want error", tc.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SetSchedule(%q): %v", tc.s, err)
+			continue
+		}
+		changed := map[int]bool{}
+		for _, d := range tc.days {
+			changed[d] = true
+		}
+		for d, row := range p.Schedule {
+			for i, ref := range row {
+				want := "home"
+				if changed[d] && i >= tc.from && i < tc.to {
+					want = ch.ClimateRef
+				}
+				if ref != want {
+					t.Errorf("SetSchedule(%q): %s slot %d = %q, want %q", tc.s, ecobee.Weekdays[d], i, ref, want)
+				}
+			}
+		}
+		if err := p.Validate(); err != nil {
+			t.Errorf("SetSchedule(%q) left an invalid program: %v", tc.s, err)
+		}
+	}
+}
+
+func TestSetScheduleEmpty(t *testing.T) {
+	p := ecobee.Program{Climates: testProgram().Climates}
+	err := p.SetSchedule(ecobee.ScheduleChange{
+		Days:          []time.Weekday{time.Tuesday},
+		ScheduleRange: ecobee.ScheduleRange{Start: 6 * time.Hour, End: 7 * time.Hour, ClimateRef: "away"},
+	})
+	if err != nil {
+		t.Fatalf("SetSchedule: %v", err)
+	}
+	if len(p.Schedule) != 7 || len(p.Schedule[1]) != 48 || p.Schedule[1][12] != "away" {
+		t.Errorf("schedule = %v, want 7 days with Tuesday filled in", p.Schedule)
+	}
+
+	if err := p.SetSchedule(ecobee.ScheduleChange{ScheduleRange: ecobee.ScheduleRange{End: time.Hour, ClimateRef: "away"}}); err == nil {
+		t.Error("SetSchedule without days succeeded")
+	}
+}
@@ -0,0 +1,361 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// RuntimeInterval is the resolution of runtime reports.
+const RuntimeInterval = 5 * time.Minute
+
+const (
+	// maxReportSpan is the longest time range fetched by one report
+	// request.  Its dates span at most 31 days, the API's limit.
+	maxReportSpan = 30 * 24 * time.Hour
+	// maxReportThermostats is the most thermostats one report
+	// request may select.
+	maxReportThermostats = 25
+)
+
+// DefaultRuntimeColumns are commonly useful runtime report columns.
+// Temperatures are in degrees Fahrenheit, equipment runtimes in
+// seconds per interval.  See the ecobee runtimeReport documentation
+// for all columns.
+var DefaultRuntimeColumns = []string{
+	"hvacMode", "zoneClimate", "zoneCalendarEvent",
+	"zoneAveTemp", "zoneHumidity", "zoneHeatTemp", "zoneCoolTemp",
+	"outdoorTemp", "outdoorHumidity",
+	"compHeat1", "compHeat2", "compCool1", "compCool2",
+	"auxHeat1", "auxHeat2", "fan",
+}
+
+// RuntimeReportRequest is the request of the runtimeReport endpoint.
+// Dates and intervals are in UTC; intervals number the 5 minute
+// periods of a day from 0 to 287.
+type RuntimeReportRequest struct {
+	Selection      Selection `json:"selection"`
+	StartDate      string    `json:"startDate"`
+	StartInterval  int       `json:"startInterval"`
+	EndDate        string    `json:"endDate"`
+	EndInterval    int       `json:"endInterval"`
+	Columns        string    `json:"columns"`
+	IncludeSensors bool      `json:"includeSensors"`
+}
+
+type RuntimeReportResponse struct {
+	StartDate     string              `json:"startDate"`
+	StartInterval int                 `json:"startInterval"`
+	EndDate       string              `json:"endDate"`
+	EndInterval   int                 `json:"endInterval"`
+	Columns       string              `json:"columns"`
+	ReportList    []RuntimeReportRows `json:"reportList"`
+	SensorList    []RuntimeSensorRows `json:"sensorList"`
+	Status        Status              `json:"status"`
+}
+
+// RuntimeReportRows are the rows of one thermostat.  Each row is
+// "date,time," followed by the requested columns, in the thermostat's
+// time zone.
+type RuntimeReportRows struct {
+	ThermostatIdentifier string   `json:"thermostatIdentifier"`
+	RowCount             int      `json:"rowCount"`
+	RowList              []string `json:"rowList"`
+}
+
+// RuntimeSensorRows are the sensor readings of one thermostat.  Columns
+// starts with "date" and "time", followed by sensor IDs.
+type RuntimeSensorRows struct {
+	ThermostatIdentifier string          `json:"thermostatIdentifier"`
+	Sensors              []RuntimeSensor `json:"sensors"`
+	Columns              []string        `json:"columns"`
+	Data                 []string        `json:"data"`
+}
+
+type RuntimeSensor struct {
+	SensorID    string `json:"sensorId"`
+	SensorName  string `json:"sensorName"`
+	SensorType  string `json:"sensorType"`
+	SensorUsage string `json:"sensorUsage"`
+}
+
+// RuntimeRow is one 5 minute interval of a runtime report.
+type RuntimeRow struct {
+	Thermostat string
+	Time       time.Time
+	// Values holds the non-empty values by column name, or by
+	// sensor ID for sensor readings.
+	Values map[string]string
+}
+
+// Float returns the value of col as a number, and whether it has one.
+func (r RuntimeRow) Float(col string) (float64, bool) {
+	v, ok := r.Values[col]
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	return f, err == nil
+}
+
+// Temperature returns the value of a temperature column such as
+// zoneAveTemp, and whether it has one.
+func (r RuntimeRow) Temperature(col string) (Temperature, bool) {
+	f, ok := r.Float(col)
+	return FromFahrenheit(f), ok
+}
+
+// Runtime returns the value of an equipment column such as compCool1,
+// and whether it has one.
+func (r RuntimeRow) Runtime(col string) (time.Duration, bool) {
+	f, ok := r.Float(col)
+	return time.Duration(f * float64(time.Second)), ok
+}
+
+// RuntimeReport is the decoded result of GetRuntimeReport.
+type RuntimeReport struct {
+	// Columns are the requested columns, in order.
+	Columns []string
+	// Rows are ordered by thermostat, as requested, and time.
+	Rows []RuntimeRow
+	// Sensors are the sensors of each thermostat, by identifier,
+	// when sensors were requested.
+	Sensors map[string][]RuntimeSensor
+	// SensorRows hold the sensor readings keyed by sensor ID,
+	// ordered like Rows.
+	SensorRows []RuntimeRow
+}
+
+// GetRuntimeReport fetches the runtime report of thermostats, a comma
+// separated list of identifiers, for the 5 minute intervals from start
+// up to end.  Long ranges and many thermostats are split into several
+// requests.  If includeSensors is set, remote sensor readings are
+// included too.
+func (c *Client) GetRuntimeReport(thermostats string, start, end time.Time, columns []string, includeSensors bool) (*RuntimeReport, error) {
+	return c.GetRuntimeReportContext(context.Background(), thermostats, start, end, columns, includeSensors)
+}
+
+// GetRuntimeReportContext is like GetRuntimeReport, using ctx for the
+// requests.
+func (c *Client) GetRuntimeReportContext(ctx context.Context, thermostats string, start, end time.Time, columns []string, includeSensors bool) (*RuntimeReport, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("report end %v is not after start %v", end, start)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no report columns")
+	}
+	ids := strings.Split(thermostats, ",")
+
+	r := &RuntimeReport{Columns: columns}
+	if includeSensors {
+		r.Sensors = map[string][]RuntimeSensor{}
+	}
+	for _, batch := range reportBatches(ids) {
+		for _, w := range reportWindows(start, end) {
+			req := RuntimeReportRequest{
+				Selection: Selection{
+					SelectionType:  SelectionTypeThermostats,
+					SelectionMatch: batch,
+				},
+				StartDate:      w.startDate,
+				StartInterval:  w.startInterval,
+				EndDate:        w.endDate,
+				EndInterval:    w.endInterval,
+				Columns:        strings.Join(columns, ","),
+				IncludeSensors: includeSensors,
+			}
+			if err := c.runtimeReport(ctx, req, r); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, rows := range [][]RuntimeRow{r.Rows, r.SensorRows} {
+		sortReportRows(ids, rows)
+	}
+	return r, nil
+}
+
+// reportBatches splits ids into comma separated lists small enough
+// for one report request.
+func reportBatches(ids []string) []string {
+	var bs []string
+	for i := 0; i < len(ids); i += maxReportThermostats {
+		bs = append(bs, strings.Join(ids[i:min(i+maxReportThermostats, len(ids))], ","))
+	}
+	return bs
+}
+
+// reportWindow is the range of UTC intervals fetched by one report
+// request.  Both ends are inclusive.
+type reportWindow struct {
+	startDate     string
+	startInterval int
+	endDate       string
+	endInterval   int
+}
+
+// reportWindows splits the intervals from start up to end into
+// windows small enough for one report request.
+func reportWindows(start, end time.Time) []reportWindow {
+	var ws []reportWindow
+	last := end.Add(-1).UTC().Truncate(RuntimeInterval)
+	for from := start.UTC().Truncate(RuntimeInterval); !from.After(last); {
+		to := from.Add(maxReportSpan - RuntimeInterval)
+		if to.After(last) {
+			to = last
+		}
+		ws = append(ws, reportWindow{
+			startDate:     from.Format(DateLayout),
+			startInterval: reportInterval(from),
+			endDate:       to.Format(DateLayout),
+			endInterval:   reportInterval(to),
+		})
+		from = to.Add(RuntimeInterval)
+	}
+	return ws
+}
+
+// sortReportRows orders rows by thermostat, in the order of ids, and
+// time.
+func sortReportRows(ids []string, rows []RuntimeRow) {
+	order := map[string]int{}
+	for i, id := range ids {
+		order[id] = i
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Thermostat != rows[j].Thermostat {
+			return order[rows[i].Thermostat] < order[rows[j].Thermostat]
+		}
+		return rows[i].Time.Before(rows[j].Time)
+	})
+}
+
+// reportInterval returns the number of the 5 minute interval of the UTC
+// day that t is in.
+func reportInterval(t time.Time) int {
+	t = t.UTC()
+	return (t.Hour()*60 + t.Minute()) / 5
+}
+
+// runtimeReport sends a single report request and adds its rows to r.
+func (c *Client) runtimeReport(ctx context.Context, req RuntimeReportRequest, r *RuntimeReport) error {
+	if err := req.Selection.Validate(); err != nil {
+		return err
+	}
+	j, err := json.Marshal(&req)
+	if err != nil {
+		return fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	body, err := c.getReport(ctx, c.url(runtimeReportPath), j)
+	if err != nil {
+		return fmt.Errorf("error fetching runtime report: %w", err)
+	}
+
+	var resp RuntimeReportResponse
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("error unmarshalling json: %v", err)
+	}
+
+	glog.V(1).Infof("GetRuntimeReport response: %d reports, %d sensor reports", len(resp.ReportList), len(resp.SensorList))
+
+	if err := statusError(c.url(runtimeReportPath), resp.Status); err != nil {
+		return err
+	}
+
+	if len(resp.ReportList) == 0 && len(resp.SensorList) == 0 {
+		return nil
+	}
+	// One request resolves the time zones of the whole batch.
+	zones, err := c.ThermostatTimeZones(ctx, req.Selection.SelectionMatch)
+	if err != nil {
+		return err
+	}
+	for _, rl := range resp.ReportList {
+		loc, err := reportZone(zones, rl.ThermostatIdentifier)
+		if err != nil {
+			return err
+		}
+		rows, err := parseReportRows(rl.ThermostatIdentifier, r.Columns, rl.RowList, loc)
+		if err != nil {
+			return err
+		}
+		r.Rows = append(r.Rows, rows...)
+	}
+	for _, sl := range resp.SensorList {
+		if len(sl.Columns) < 2 {
+			return fmt.Errorf("invalid sensor columns %q for thermostat %s", sl.Columns, sl.ThermostatIdentifier)
+		}
+		loc, err := reportZone(zones, sl.ThermostatIdentifier)
+		if err != nil {
+			return err
+		}
+		rows, err := parseReportRows(sl.ThermostatIdentifier, sl.Columns[2:], sl.Data, loc)
+		if err != nil {
+			return err
+		}
+		if _, ok := r.Sensors[sl.ThermostatIdentifier]; !ok {
+			r.Sensors[sl.ThermostatIdentifier] = sl.Sensors
+		}
+		r.SensorRows = append(r.SensorRows, rows...)
+	}
+	return nil
+}
+
+// reportZone returns the time zone of thermostat from zones, which
+// holds those of the thermostats requested.
+func reportZone(zones map[string]*time.Location, thermostat string) (*time.Location, error) {
+	loc, ok := zones[thermostat]
+	if !ok {
+		return nil, fmt.Errorf("report for thermostat %s, which wasn't requested", thermostat)
+	}
+	return loc, nil
+}
+
+// parseReportRows parses rows of the form "date,time,value,...", with
+// one value per column, in the time zone loc.
+func parseReportRows(thermostat string, columns, rows []string, loc *time.Location) ([]RuntimeRow, error) {
+	var rs []RuntimeRow
+	for _, row := range rows {
+		cr := csv.NewReader(strings.NewReader(row))
+		cr.FieldsPerRecord = len(columns) + 2
+		f, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("invalid report row %q for thermostat %s: %v", row, thermostat, err)
+		}
+		t, err := ParseDateTime(f[0], f[1], loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid report row %q for thermostat %s: %v", row, thermostat, err)
+		}
+		rr := RuntimeRow{Thermostat: thermostat, Time: t, Values: map[string]string{}}
+		for i, v := range f[2:] {
+			if v = strings.TrimSpace(v); v != "" {
+				rr.Values[columns[i]] = v
+			}
+		}
+		rs = append(rs, rr)
+	}
+	return rs, nil
+}
@@ -0,0 +1,180 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportBatches(t *testing.T) {
+	ids := func(from, to int) []string {
+		var ids []string
+		for i := from; i < to; i++ {
+			ids = append(ids, fmt.Sprint(100+i))
+		}
+		return ids
+	}
+	join := func(from, to int) string { return strings.Join(ids(from, to), ",") }
+	for _, tc := range []struct {
+		n    int
+		want []string
+	}{
+		{0, nil},
+		{1, []string{"100"}},
+		{25, []string{join(0, 25)}},
+		{26, []string{join(0, 25), "125"}},
+		{50, []string{join(0, 25), join(25, 50)}},
+		{51, []string{join(0, 25), join(25, 50), "150"}},
+	} {
+		if got := reportBatches(ids(0, tc.n)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("reportBatches(%d ids) = %q, want %q", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestReportWindows(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2017, 12, d, 0, 0, 0, 0, time.UTC) }
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name       string
+		start, end time.Time
+		want       []reportWindow
+	}{
+		{"empty", day(1), day(1), nil},
+		{"one interval", day(1), day(1).Add(5 * time.Minute), []reportWindow{{"2017-12-01", 0, "2017-12-01", 0}}},
+		{"unaligned", day(1).Add(3 * time.Minute), day(1).Add(7 * time.Minute), []reportWindow{{"2017-12-01", 0, "2017-12-01", 1}}},
+		{"one day", day(1), day(2), []reportWindow{{"2017-12-01", 0, "2017-12-01", 287}}},
+		{"30 days", day(1), day(31), []reportWindow{{"2017-12-01", 0, "2017-12-30", 287}}},
+		{
+			"30 days and an interval", day(1), day(31).Add(5 * time.Minute),
+			[]reportWindow{{"2017-12-01", 0, "2017-12-30", 287}, {"2017-12-31", 0, "2017-12-31", 0}},
+		},
+		{
+			"61 days", day(1), day(1).AddDate(0, 0, 61),
+			[]reportWindow{
+				{"2017-12-01", 0, "2017-12-30", 287},
+				{"2017-12-31", 0, "2018-01-29", 287},
+				{"2018-01-30", 0, "2018-01-30", 287},
+			},
+		},
+		{
+			"local time", time.Date(2017, 12, 1, 0, 0, 0, 0, toronto), time.Date(2017, 12, 1, 1, 0, 0, 0, toronto),
+			[]reportWindow{{"2017-12-01", 60, "2017-12-01", 71}},
+		},
+		{
+			"across UTC midnight", time.Date(2017, 12, 1, 18, 0, 0, 0, toronto), time.Date(2017, 12, 1, 20, 0, 0, 0, toronto),
+			[]reportWindow{{"2017-12-01", 276, "2017-12-02", 11}},
+		},
+	} {
+		if got := reportWindows(tc.start, tc.end); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: reportWindows = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseReportRows(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []string{"zoneAveTemp", "hvacMode", "zoneClimate"}
+	for _, tc := range []struct {
+		row     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{row: "2017-12-01,07:00:00,71.2,heat,home", want: map[string]string{"zoneAveTemp": "71.2", "hvacMode": "heat", "zoneClimate": "home"}},
+		{row: "2017-12-01,07:00:00,,heat, ", want: map[string]string{"hvacMode": "heat"}},
+		{row: `2017-12-01,07:00:00,71.2,heat,"Night, Owl"`, want: map[string]string{"zoneAveTemp": "71.2", "hvacMode": "heat", "zoneClimate": "Night, Owl"}},
+		{row: "2017-12-01,07:00:00,71.2,heat", wantErr: true},
+		{row: "2017-12-01,07:00:00,71.2,heat,home,extra", wantErr: true},
+		{row: "12/01/2017,07:00:00,71.2,heat,home", wantErr: true},
+		{row: "2017-12-01,7am,71.2,heat,home", wantErr: true},
+	} {
+		rows, err := parseReportRows("123", columns, []string{tc.row}, toronto)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseReportRows(%q) = %+v, want error", tc.row, rows)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseReportRows(%q): %v", tc.row, err)
+			continue
+		}
+		r := rows[0]
+		if want := time.Date(2017, 12, 1, 12, 0, 0, 0, time.UTC); r.Thermostat != "123" || !r.Time.Equal(want) {
+			t.Errorf("parseReportRows(%q) = %s at %v, want 123 at %v", tc.row, r.Thermostat, r.Time, want)
+		}
+		if !reflect.DeepEqual(r.Values, tc.want) {
+			t.Errorf("parseReportRows(%q) values = %v, want %v", tc.row, r.Values, tc.want)
+		}
+	}
+}
+
+func TestParseReportFile(t *testing.T) {
+	zones := map[string]*time.Location{"123": time.UTC}
+	for _, tc := range []struct {
+		name, data string
+		want       []map[string]string
+	}{
+		{"no header", "2017-12-01,12:00:00,71.2\n", []map[string]string{{"zoneAveTemp": "71.2"}}},
+		{"header", "date,time,outdoorTemp\n2017-12-01,12:00:00,30\n", []map[string]string{{"outdoorTemp": "30"}}},
+		{"capitalized header", "Date,Time,outdoorTemp\r\n2017-12-01,12:00:00,30\r\n", []map[string]string{{"outdoorTemp": "30"}}},
+		{"header only", "date,time,zoneAveTemp\n", nil},
+		{"blank lines", "\ndate,time,zoneAveTemp\n\n2017-12-01,12:00:00,71.2\n\n", []map[string]string{{"zoneAveTemp": "71.2"}}},
+		{"empty", "", nil},
+	} {
+		var got []map[string]string
+		err := parseReportFile("123.csv", strings.NewReader(tc.data), []string{"zoneAveTemp"}, zones, func(r RuntimeRow) error {
+			got = append(got, r.Values)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: parseReportFile: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: parseReportFile = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseReportFileErrors(t *testing.T) {
+	zones := map[string]*time.Location{"123": time.UTC}
+	row := "2017-12-01,12:00:00,71.2\n"
+	if err := parseReportFile("456.csv", strings.NewReader(row), []string{"zoneAveTemp"}, zones, func(RuntimeRow) error { return nil }); err == nil {
+		t.Error("parseReportFile of a thermostat without a time zone succeeded")
+	}
+
+	// An error from fn stops parsing.
+	stop := errors.New("stop")
+	n := 0
+	err := parseReportFile("123.csv", strings.NewReader(strings.Repeat(row, 3)), []string{"zoneAveTemp"}, zones, func(RuntimeRow) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("parseReportFile = %v after %d rows, want %v after 1", err, n, stop)
+	}
+}
@@ -0,0 +1,362 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Runtime report jobs produce runtime reports too large for
+// GetRuntimeReport, such as a year of history for many thermostats.
+// A job is created, polled until ecobee has written its files, and the
+// files downloaded.
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Report job states.
+const (
+	JobQueued     = "queued"
+	JobProcessing = "processing"
+	JobCompleted  = "completed"
+	JobCancelled  = "cancelled"
+	JobError      = "error"
+)
+
+// Polling intervals of WaitReportJob.
+const (
+	reportJobMinPoll = 5 * time.Second
+	reportJobMaxPoll = 2 * time.Minute
+)
+
+// RuntimeReportJobRequest is the request of the runtimeReportJob/create
+// endpoint.  Dates are in UTC, and both are included.
+type RuntimeReportJobRequest struct {
+	Selection      Selection `json:"selection"`
+	StartDate      string    `json:"startDate"`
+	EndDate        string    `json:"endDate"`
+	Columns        string    `json:"columns"`
+	IncludeSensors bool      `json:"includeSensors"`
+}
+
+type RuntimeReportJobResponse struct {
+	JobID  string `json:"jobId"`
+	Status Status `json:"status"`
+}
+
+// ReportJobRequest identifies a job for the runtimeReportJob/status and
+// runtimeReportJob/cancel endpoints.
+type ReportJobRequest struct {
+	JobID string `json:"jobId"`
+}
+
+// ReportJob is the state of a report job.  Files are the URLs of the
+// report files once the job is completed.
+type ReportJob struct {
+	JobID   string   `json:"jobId"`
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+}
+
+// Done reports whether the job has finished, successfully or not.
+func (j *ReportJob) Done() bool {
+	switch j.Status {
+	case JobCompleted, JobCancelled, JobError:
+		return true
+	}
+	return false
+}
+
+type ReportJobResponse struct {
+	Jobs   []ReportJob `json:"jobs"`
+	Status Status      `json:"status"`
+}
+
+// CreateRuntimeReportJob starts a job producing the runtime report of
+// the thermostats matched by selection for the UTC days from start to
+// end, inclusive, and returns its ID.  If includeSensors is set, the
+// files also hold the readings of remote sensors, in columns named by
+// sensor ID.
+func (c *Client) CreateRuntimeReportJob(selection Selection, start, end time.Time, columns []string, includeSensors bool) (string, error) {
+	return c.CreateRuntimeReportJobContext(context.Background(), selection, start, end, columns, includeSensors)
+}
+
+// CreateRuntimeReportJobContext is like CreateRuntimeReportJob, using
+// ctx for the request.
+func (c *Client) CreateRuntimeReportJobContext(ctx context.Context, selection Selection, start, end time.Time, columns []string, includeSensors bool) (string, error) {
+	if err := selection.Validate(); err != nil {
+		return "", err
+	}
+	if end.Before(start) {
+		return "", fmt.Errorf("report end %v is before start %v", end, start)
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("no report columns")
+	}
+	req := RuntimeReportJobRequest{
+		Selection: selection,
+		StartDate: start.UTC().Format(DateLayout),
+		EndDate:   end.UTC().Format(DateLayout),
+		Columns:   strings.Join(columns, ","),
+
+		IncludeSensors: includeSensors,
+	}
+	j, err := json.Marshal(&req)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	glog.V(1).Infof("CreateRuntimeReportJob request: %s", j)
+
+	// Creating a job twice would start two jobs.
+	body, err := c.post(ctx, c.url(reportJobCreatePath), j, false)
+	if err != nil {
+		return "", err
+	}
+
+	var r RuntimeReportJobResponse
+	if err = json.Unmarshal(body, &r); err != nil {
+		return "", fmt.Errorf("error unmarshalling json: %v", err)
+	}
+	if err := statusError(c.url(reportJobCreatePath), r.Status); err != nil {
+		return "", err
+	}
+	if r.JobID == "" {
+		return "", fmt.Errorf("no job ID in response from %s", c.url(reportJobCreatePath))
+	}
+	return r.JobID, nil
+}
+
+// GetReportJob fetches the state of report job id.
+func (c *Client) GetReportJob(id string) (*ReportJob, error) {
+	return c.GetReportJobContext(context.Background(), id)
+}
+
+// GetReportJobContext is like GetReportJob, using ctx for the request.
+func (c *Client) GetReportJobContext(ctx context.Context, id string) (*ReportJob, error) {
+	j, err := json.Marshal(&ReportJobRequest{JobID: id})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	body, err := c.get(ctx, c.url(reportJobStatusPath), j)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching report job: %w", err)
+	}
+
+	var r ReportJobResponse
+	if err = json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("error unmarshalling json: %v", err)
+	}
+
+	glog.V(1).Infof("GetReportJob response: %#v", r)
+
+	if err := statusError(c.url(reportJobStatusPath), r.Status); err != nil {
+		return nil, err
+	}
+	for i := range r.Jobs {
+		if r.Jobs[i].JobID == id {
+			return &r.Jobs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no report job %q", id)
+}
+
+// CancelReportJob cancels report job id.
+func (c *Client) CancelReportJob(id string) error {
+	return c.CancelReportJobContext(context.Background(), id)
+}
+
+// CancelReportJobContext is like CancelReportJob, using ctx for the
+// request.
+func (c *Client) CancelReportJobContext(ctx context.Context, id string) error {
+	j, err := json.Marshal(&ReportJobRequest{JobID: id})
+	if err != nil {
+		return fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	body, err := c.post(ctx, c.url(reportJobCancelPath), j, true)
+	if err != nil {
+		return err
+	}
+
+	var r ReportJobResponse
+	if err = json.Unmarshal(body, &r); err != nil {
+		return fmt.Errorf("error unmarshalling json: %v", err)
+	}
+	return statusError(c.url(reportJobCancelPath), r.Status)
+}
+
+// WaitReportJob polls report job id until it is done, backing off from
+// a few seconds to a few minutes between polls, and returns its final
+// state.  progress, if not nil, is called with the state after each
+// poll.  A job that was cancelled or failed is returned with an error.
+func (c *Client) WaitReportJob(ctx context.Context, id string, progress func(*ReportJob)) (*ReportJob, error) {
+	d := reportJobMinPoll
+	for {
+		j, err := c.GetReportJobContext(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(j)
+		}
+		switch j.Status {
+		case JobCompleted:
+			return j, nil
+		case JobCancelled, JobError:
+			return j, fmt.Errorf("report job %s %s: %s", id, j.Status, j.Message)
+		}
+		if err := sleep(ctx, d); err != nil {
+			return nil, err
+		}
+		d = min(d*2, reportJobMaxPoll)
+	}
+}
+
+// DownloadReportJobFile downloads a file of a completed report job and
+// calls fn with each of its rows, as they are read.  columns are the
+// columns the job was created with, and zones the time zones of its
+// thermostats, as returned by ThermostatTimeZones.  An error returned
+// by fn stops the download and is returned.
+//
+// Files are gzip compressed, and hold either one CSV file or a tar
+// archive of them.  Each CSV file holds the rows of the thermostat it is
+// named after, in the format of the runtimeReport endpoint, optionally
+// preceded by a header row starting with "date".  Rows are keyed by the
+// header's column names, which include the sensor IDs of sensor
+// readings.
+func (c *Client) DownloadReportJobFile(ctx context.Context, file string, columns []string, zones map[string]*time.Location, fn func(RuntimeRow) error) error {
+	body, err := c.download(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	zr, err := gzip.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("error decompressing %s: %v", file, err)
+	}
+	br := bufio.NewReader(zr)
+	// A short read is a CSV file too small to be an archive.
+	head, err := br.Peek(tarMagicEnd)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error decompressing %s: %v", file, err)
+	}
+
+	if !isTar(head) {
+		u, err := url.Parse(file)
+		if err != nil {
+			return err
+		}
+		return parseReportFile(path.Base(u.Path), br, columns, zones, fn)
+	}
+	tr := tar.NewReader(br)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("error reading %s: %v", file, err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := parseReportFile(path.Base(h.Name), tr, columns, zones, fn); err != nil {
+			return err
+		}
+	}
+}
+
+// tarMagicEnd is the end of the "ustar" magic in a tar header.
+const tarMagicEnd = 262
+
+// isTar reports whether b, the start of a file, is a tar archive.
+func isTar(b []byte) bool {
+	return len(b) >= tarMagicEnd && string(b[257:tarMagicEnd]) == "ustar"
+}
+
+// parseReportFile parses the CSV file name, read from r, which holds
+// the rows of the thermostat it is named after, and calls fn with each
+// row.
+func parseReportFile(name string, r io.Reader, columns []string, zones map[string]*time.Location, fn func(RuntimeRow) error) error {
+	thermostat, _, _ := strings.Cut(name, ".")
+	var loc *time.Location
+	first := true
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		l := strings.TrimRight(sc.Text(), "\r")
+		if l == "" {
+			continue
+		}
+		if first && strings.HasPrefix(strings.ToLower(l), "date,") {
+			columns = strings.Split(l, ",")[2:]
+			first = false
+			continue
+		}
+		first = false
+		if loc == nil {
+			var err error
+			if loc, err = reportZone(zones, thermostat); err != nil {
+				return fmt.Errorf("report file %s: %v", name, err)
+			}
+		}
+		rs, err := parseReportRows(thermostat, columns, []string{l}, loc)
+		if err != nil {
+			return err
+		}
+		if err := fn(rs[0]); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("report file %s: %v", name, err)
+	}
+	return nil
+}
+
+// download fetches a report file, whose body the caller must close.
+// File URLs are pre-authorized, so the request is sent without the
+// access token.
+func (c *Client) download(ctx context.Context, file string) (io.ReadCloser, error) {
+	hc := *c.Client
+	if t, ok := hc.Transport.(*transport); ok {
+		hc.Transport = t.base
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s: %w", file, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("error downloading %s: %s", file, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,180 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+// csvFile is a file of a report job.
+type csvFile struct {
+	name, data string
+}
+
+// gzipFile compresses the single file f, or a tar archive of files.
+func gzipFile(t *testing.T, files ...csvFile) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	if len(files) == 1 {
+		zw.Write([]byte(files[0].data))
+	} else {
+		tw := tar.NewWriter(zw)
+		for _, f := range files {
+			if err := tw.WriteHeader(&tar.Header{Name: "report/" + f.name, Mode: 0644, Size: int64(len(f.data))}); err != nil {
+				t.Fatal(err)
+			}
+			tw.Write([]byte(f.data))
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestDownloadReportJobFile(t *testing.T) {
+	_, c := newTestServer(t)
+	files := map[string][]byte{}
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b)
+	}))
+	defer fs.Close()
+	zones, err := c.ThermostatTimeZones(context.Background(), "123,456")
+	if err != nil {
+		t.Fatalf("ThermostatTimeZones: %v", err)
+	}
+
+	// 12:00 UTC is 07:00 in Toronto.
+	long := "date,time,zoneAveTemp,outdoorTemp\n" + strings.Repeat("2017-12-01,07:00:00,71.2,30\r\n", 20)
+	for _, tc := range []struct {
+		name     string
+		files    []csvFile
+		columns  []string
+		wantRows map[string]int
+		wantErr  bool
+	}{
+		{
+			name:     "header",
+			files:    []csvFile{{"123.csv", "date,time,zoneAveTemp\n2017-12-01,07:00:00,71.2\n2017-12-01,07:05:00,71.3\n"}},
+			columns:  []string{"outdoorTemp"},
+			wantRows: map[string]int{"123": 2},
+		},
+		{
+			name:     "no header",
+			files:    []csvFile{{"123.csv", "2017-12-01,07:00:00,71.2\n"}},
+			columns:  []string{"zoneAveTemp"},
+			wantRows: map[string]int{"123": 1},
+		},
+		{
+			name:     "long",
+			files:    []csvFile{{"123.csv", long}},
+			columns:  []string{"zoneAveTemp", "outdoorTemp"},
+			wantRows: map[string]int{"123": 20},
+		},
+		{
+			name:     "empty",
+			files:    []csvFile{{"123.csv", "date,time,zoneAveTemp\n"}},
+			columns:  []string{"zoneAveTemp"},
+			wantRows: map[string]int{},
+		},
+		{
+			name: "archive",
+			files: []csvFile{
+				{"123.csv", long},
+				{"456.csv", "date,time,zoneAveTemp\n2017-12-01,07:00:00,71.2\n"},
+			},
+			columns:  []string{"zoneAveTemp"},
+			wantRows: map[string]int{"123": 20, "456": 1},
+		},
+		{
+			name:    "bad row",
+			files:   []csvFile{{"123.csv", "2017-12-01,07:00:00,71.2,30\n"}},
+			columns: []string{"zoneAveTemp"},
+			wantErr: true,
+		},
+	} {
+		path := "/" + strings.ReplaceAll(tc.name, " ", "-") + ".csv.gz"
+		if len(tc.files) == 1 {
+			path = "/" + tc.files[0].name + ".gz"
+		}
+		files[path] = gzipFile(t, tc.files...)
+
+		var rows []ecobee.RuntimeRow
+		err := c.DownloadReportJobFile(context.Background(), fs.URL+path, tc.columns, zones, func(r ecobee.RuntimeRow) error {
+			rows = append(rows, r)
+			return nil
+		})
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: DownloadReportJobFile = %v, want error", tc.name, rows)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: DownloadReportJobFile: %v", tc.name, err)
+			continue
+		}
+		got := map[string]int{}
+		for _, r := range rows {
+			got[r.Thermostat]++
+		}
+		if len(got) != len(tc.wantRows) {
+			t.Errorf("%s: got rows %v, want %v", tc.name, got, tc.wantRows)
+		}
+		for id, n := range tc.wantRows {
+			if got[id] != n {
+				t.Errorf("%s: got %d rows of %s, want %d", tc.name, got[id], id, n)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		r := rows[0]
+		if want := time.Date(2017, 12, 1, 12, 0, 0, 0, time.UTC); !r.Time.Equal(want) {
+			t.Errorf("%s: first row at %v, want %v", tc.name, r.Time, want)
+		}
+		if v := r.Values["zoneAveTemp"]; v != "71.2" {
+			t.Errorf("%s: zoneAveTemp = %q, want 71.2", tc.name, v)
+		}
+	}
+
+	ignore := func(ecobee.RuntimeRow) error { return nil }
+	if err := c.DownloadReportJobFile(context.Background(), fs.URL+"/missing.csv.gz", []string{"zoneAveTemp"}, zones, ignore); err == nil {
+		t.Error("DownloadReportJobFile of a missing file succeeded")
+	}
+	files["/789.csv.gz"] = gzipFile(t, csvFile{"789.csv", "2017-12-01,07:00:00,71.2\n"})
+	if err := c.DownloadReportJobFile(context.Background(), fs.URL+"/789.csv.gz", []string{"zoneAveTemp"}, zones, ignore); err == nil {
+		t.Error("DownloadReportJobFile of a thermostat without a time zone succeeded")
+	}
+}
@@ -0,0 +1,123 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for _, tc := range []struct {
+		n   int
+		max time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{6, time.Second},
+	} {
+		for i := 0; i < 1000; i++ {
+			if d := p.backoff(tc.n); d <= 0 || d > tc.max {
+				t.Fatalf("backoff(%d) = %v, want in (0, %v]", tc.n, d, tc.max)
+			}
+		}
+	}
+
+	if d := (RetryPolicy{}).backoff(3); d != 0 {
+		t.Errorf("backoff without BaseDelay = %v, want 0", d)
+	}
+	// Without MaxDelay, the delay keeps doubling.
+	p.MaxDelay = 0
+	if max := p.BaseDelay << 9; p.backoff(10) > max {
+		t.Errorf("backoff(10) above %v", max)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	read := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}
+	for _, tc := range []struct {
+		name                  string
+		err                   error
+		idempotent, otherwise bool
+	}{
+		{"HTTP 503", &APIError{HTTPStatus: http.StatusServiceUnavailable}, true, false},
+		{"HTTP 429", &APIError{HTTPStatus: http.StatusTooManyRequests}, true, false},
+		{"processing error", &APIError{HTTPStatus: 500, Code: 3}, true, false},
+		{"validation error", &APIError{HTTPStatus: 500, Code: 7}, false, false},
+		{"token expired", &APIError{HTTPStatus: 500, Code: 14}, false, false},
+		{"HTTP 400", &APIError{HTTPStatus: http.StatusBadRequest}, false, false},
+		{"dial error", fmt.Errorf("error on post request: %w", dial), true, true},
+		{"read error", fmt.Errorf("error on post request: %w", read), true, false},
+		{"canceled", fmt.Errorf("error on get request: %w", context.Canceled), false, false},
+		{"deadline", context.DeadlineExceeded, false, false},
+		{"other", errors.New("error marshaling json"), false, false},
+	} {
+		if got := retryable(tc.err, true); got != tc.idempotent {
+			t.Errorf("%s: retryable(idempotent) = %v, want %v", tc.name, got, tc.idempotent)
+		}
+		if got := retryable(tc.err, false); got != tc.otherwise {
+			t.Errorf("%s: retryable(not idempotent) = %v, want %v", tc.name, got, tc.otherwise)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(50, 2)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := l.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d > 15*time.Millisecond {
+		t.Errorf("burst of 2 took %v, want no wait", d)
+	}
+	for i := 0; i < 3; i++ {
+		if err := l.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 3 requests past the burst at 50/s take 60ms.
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("5 requests took %v, want at least 60ms", d)
+	}
+
+	// A cancelled wait returns its token.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	before := l.tokens
+	if err := l.wait(cctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait with cancelled context = %v, want context.Canceled", err)
+	}
+	if l.tokens < before {
+		t.Errorf("cancelled wait kept its token: %v -> %v", before, l.tokens)
+	}
+
+	var none *rateLimiter
+	if err := none.wait(ctx); err != nil {
+		t.Errorf("nil limiter wait = %v", err)
+	}
+}
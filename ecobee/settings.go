@@ -0,0 +1,116 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// GetSettings fetches the settings of a single thermostat.
+func (c *Client) GetSettings(thermostat string) (*Settings, error) {
+	return c.GetSettingsContext(context.Background(), thermostat)
+}
+
+// GetSettingsContext is like GetSettings, using ctx for the request.
+func (c *Client) GetSettingsContext(ctx context.Context, thermostat string) (*Settings, error) {
+	ts, err := c.GetThermostatsContext(ctx, Selection{
+		SelectionType:   SelectionTypeThermostats,
+		SelectionMatch:  thermostat,
+		IncludeSettings: true,
+	})
+	if err != nil {
+		return nil, err
+	} else if len(ts) != 1 {
+		return nil, fmt.Errorf("got %d thermostats, wanted 1", len(ts))
+	}
+	return &ts[0].Settings, nil
+}
+
+// UpdateSettings writes the settings that differ between old and new to
+// the thermostats matched by thermostat.  Settings that are the same in
+// both are not sent, so concurrent changes to them are preserved.
+// Typically old is the result of GetSettings and new a modified copy.
+func (c *Client) UpdateSettings(thermostat string, old, new Settings) error {
+	return c.UpdateSettingsContext(context.Background(), thermostat, old, new)
+}
+
+// UpdateSettingsContext is like UpdateSettings, using ctx for the
+// request.
+func (c *Client) UpdateSettingsContext(ctx context.Context, thermostat string, old, new Settings) error {
+	changes, err := settingsChanges(old, new)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return c.UpdateSettingsFieldsContext(ctx, thermostat, changes)
+}
+
+// UpdateSettingsFields writes the given settings, keyed by their API
+// names (e.g. "hvacMode"), to the thermostats matched by thermostat.
+func (c *Client) UpdateSettingsFields(thermostat string, fields map[string]interface{}) error {
+	return c.UpdateSettingsFieldsContext(context.Background(), thermostat, fields)
+}
+
+// UpdateSettingsFieldsContext is like UpdateSettingsFields, using ctx
+// for the request.
+func (c *Client) UpdateSettingsFieldsContext(ctx context.Context, thermostat string, fields map[string]interface{}) error {
+	r := &UpdateThermostatRequest{
+		Selection: Selection{
+			SelectionType:  SelectionTypeThermostats,
+			SelectionMatch: thermostat,
+		},
+		Thermostat: &ThermostatUpdate{
+			Settings: fields,
+		},
+	}
+	return c.UpdateThermostatContext(ctx, *r)
+}
+
+// settingsChanges returns the JSON fields of new that differ from old.
+func settingsChanges(old, new Settings) (map[string]interface{}, error) {
+	om, err := toJSONMap(old)
+	if err != nil {
+		return nil, err
+	}
+	nm, err := toJSONMap(new)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]interface{}{}
+	for k, v := range nm {
+		if !reflect.DeepEqual(om[k], v) {
+			changes[k] = v
+		}
+	}
+	return changes, nil
+}
+
+// toJSONMap returns the JSON object representation of v.
+func toJSONMap(v interface{}) (map[string]interface{}, error) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling json: %v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(j, &m); err != nil {
+		return nil, fmt.Errorf("error unmarshalling json: %v", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,52 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSettingsChanges(t *testing.T) {
+	base := Settings{HvacMode: HvacModeHeat, UseCelsius: true, FanMinOnTime: 10, HeatStages: 1}
+	for _, tc := range []struct {
+		name   string
+		change func(*Settings)
+		want   map[string]interface{}
+	}{
+		{"unchanged", func(*Settings) {}, map[string]interface{}{}},
+		{"mode", func(s *Settings) { s.HvacMode = HvacModeCool }, map[string]interface{}{"hvacMode": "cool"}},
+		{"to false", func(s *Settings) { s.UseCelsius = false }, map[string]interface{}{"useCelsius": false}},
+		{"to zero", func(s *Settings) { s.FanMinOnTime = 0 }, map[string]interface{}{"fanMinOnTime": 0.0}},
+		{"from zero", func(s *Settings) { s.CoolStages = 2 }, map[string]interface{}{"coolStages": 2.0}},
+		{"several", func(s *Settings) {
+			s.UseCelsius = false
+			s.FanMinOnTime = 0
+			s.HeatStages = 2
+		}, map[string]interface{}{"useCelsius": false, "fanMinOnTime": 0.0, "heatStages": 2.0}},
+		{"temperature", func(s *Settings) { s.ColdTempAlert = 450 }, map[string]interface{}{"coldTempAlert": 450.0}},
+	} {
+		new := base
+		tc.change(&new)
+		got, err := settingsChanges(base, new)
+		if err != nil {
+			t.Errorf("%s: settingsChanges: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: settingsChanges = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Temperature is a temperature as represented by the ecobee API: an
+// integer number of tenths of a degree Fahrenheit.  72.5°F is 725.
+type Temperature int
+
+// Unit is a temperature scale.
+type Unit int
+
+const (
+	Fahrenheit Unit = iota
+	Celsius
+)
+
+func (u Unit) String() string {
+	if u == Celsius {
+		return "C"
+	}
+	return "F"
+}
+
+// ParseUnit parses "F", "C", "fahrenheit" or "celsius", ignoring case.
+func ParseUnit(s string) (Unit, error) {
+	switch strings.ToLower(strings.TrimPrefix(s, "°")) {
+	case "f", "fahrenheit":
+		return Fahrenheit, nil
+	case "c", "celsius":
+		return Celsius, nil
+	}
+	return Fahrenheit, fmt.Errorf("unknown temperature unit %q", s)
+}
+
+// FromFahrenheit returns the Temperature for f degrees Fahrenheit.
+func FromFahrenheit(f float64) Temperature {
+	return Temperature(math.Round(f * 10))
+}
+
+// FromCelsius returns the Temperature for c degrees Celsius.
+func FromCelsius(c float64) Temperature {
+	return FromFahrenheit(c*9/5 + 32)
+}
+
+// FromUnit returns the Temperature for v degrees in unit u.
+func FromUnit(v float64, u Unit) Temperature {
+	if u == Celsius {
+		return FromCelsius(v)
+	}
+	return FromFahrenheit(v)
+}
+
+// Fahrenheit returns t in degrees Fahrenheit.
+func (t Temperature) Fahrenheit() float64 {
+	return float64(t) / 10
+}
+
+// Celsius returns t in degrees Celsius.
+func (t Temperature) Celsius() float64 {
+	return (t.Fahrenheit() - 32) * 5 / 9
+}
+
+// In returns t in degrees of unit u.
+func (t Temperature) In(u Unit) float64 {
+	if u == Celsius {
+		return t.Celsius()
+	}
+	return t.Fahrenheit()
+}
+
+// Format formats t in unit u with one decimal place, without a unit
+// suffix, e.g. "72.5".
+func (t Temperature) Format(u Unit) string {
+	return strconv.FormatFloat(t.In(u), 'f', 1, 64)
+}
+
+// String formats t in Fahrenheit with a unit suffix, e.g. "72.5°F".
+func (t Temperature) String() string {
+	return t.Format(Fahrenheit) + "°F"
+}
+
+// ParseTemperature parses a temperature such as "72", "72.5F",
+// "22.5°C" or "22.5 c".  Numbers without a unit suffix are in unit
+// def.
+func ParseTemperature(s string, def Unit) (Temperature, error) {
+	v := strings.TrimSpace(s)
+	u := def
+	if i := strings.LastIndexAny(v, "0123456789."); i >= 0 && i < len(v)-1 {
+		var err error
+		u, err = ParseUnit(strings.TrimSpace(v[i+1:]))
+		if err != nil {
+			return 0, fmt.Errorf("invalid temperature %q: %v", s, err)
+		}
+		v = v[:i+1]
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid temperature %q", s)
+	}
+	return FromUnit(f, u), nil
+}
+
+// Temperature parses the value of a "temperature" capability.  ecobee
+// reports "unknown" when a sensor has no reading.
+func (c RemoteSensorCapability) Temperature() (Temperature, error) {
+	if c.Type != "temperature" {
+		return 0, fmt.Errorf("capability %s is %q, not a temperature", c.ID, c.Type)
+	}
+	v, err := strconv.Atoi(c.Value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid temperature %q from capability %s", c.Value, c.ID)
+	}
+	return Temperature(v), nil
+}
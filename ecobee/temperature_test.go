@@ -0,0 +1,87 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+func TestParseTemperature(t *testing.T) {
+	for _, tc := range []struct {
+		s       string
+		def     ecobee.Unit
+		want    ecobee.Temperature
+		wantErr bool
+	}{
+		{s: "72", def: ecobee.Fahrenheit, want: 720},
+		{s: "72.5F", def: ecobee.Celsius, want: 725},
+		{s: " 72.5 f ", def: ecobee.Celsius, want: 725},
+		{s: "22.5°C", def: ecobee.Fahrenheit, want: 725},
+		{s: "22.5 c", def: ecobee.Fahrenheit, want: 725},
+		{s: "21", def: ecobee.Celsius, want: 698},
+		{s: "20.1C", def: ecobee.Fahrenheit, want: 682},
+		{s: "-5", def: ecobee.Fahrenheit, want: -50},
+		{s: "-40", def: ecobee.Celsius, want: -400},
+		{s: "-10C", def: ecobee.Fahrenheit, want: 140},
+		{s: "-0.5C", def: ecobee.Fahrenheit, want: 311},
+		{s: "", wantErr: true},
+		{s: "F", wantErr: true},
+		{s: "abc", wantErr: true},
+		{s: "72K", wantErr: true},
+		{s: "--5", wantErr: true},
+	} {
+		got, err := ecobee.ParseTemperature(tc.s, tc.def)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseTemperature(%q) = %v, want error", tc.s, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("ParseTemperature(%q, %v) = %d, %v; want %d", tc.s, tc.def, got, err, tc.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, tc := range []struct {
+		t    ecobee.Temperature
+		f, c string
+	}{
+		{725, "72.5", "22.5"},
+		{698, "69.8", "21.0"},
+		{700, "70.0", "21.1"},
+		{682, "68.2", "20.1"},
+		{320, "32.0", "0.0"},
+		{321, "32.1", "0.1"},
+		{319, "31.9", "-0.1"},
+		{311, "31.1", "-0.5"},
+		{0, "0.0", "-17.8"},
+		{-5, "-0.5", "-18.1"},
+		{-400, "-40.0", "-40.0"},
+	} {
+		if got := tc.t.Format(ecobee.Fahrenheit); got != tc.f {
+			t.Errorf("%d.Format(F) = %q, want %q", tc.t, got, tc.f)
+		}
+		if got := tc.t.Format(ecobee.Celsius); got != tc.c {
+			t.Errorf("%d.Format(C) = %q, want %q", tc.t, got, tc.c)
+		}
+	}
+	if got := ecobee.Temperature(-400).String(); got != "-40.0°F" {
+		t.Errorf("String() = %q, want -40.0°F", got)
+	}
+}
@@ -0,0 +1,197 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// ecobee dates and times are in the thermostat's local time zone.  The
+// functions in this file convert them to and from time.Time.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Layouts of the date and time strings used by the API.
+const (
+	DateLayout     = "2006-01-02"
+	TimeLayout     = "15:04:05"
+	DateTimeLayout = DateLayout + " " + TimeLayout
+)
+
+// TimeZone returns the thermostat's time zone.  It uses the Location's
+// time zone name when it was fetched with IncludeLocation, falling back
+// to a fixed offset derived from ThermostatTime and UtcTime.  The
+// fallback knows nothing of daylight saving time, so it is only right
+// for times under the current offset; fetch the thermostat with
+// IncludeLocation to convert times across a change.
+func (t *Thermostat) TimeZone() (*time.Location, error) {
+	if t.Location.TimeZone != "" {
+		if loc, err := time.LoadLocation(t.Location.TimeZone); err == nil {
+			return loc, nil
+		}
+	}
+	if t.ThermostatTime == "" || t.UtcTime == "" {
+		return nil, fmt.Errorf("thermostat %s has no time zone information", t.Identifier)
+	}
+	local, err := time.Parse(DateTimeLayout, t.ThermostatTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid thermostatTime %q: %v", t.ThermostatTime, err)
+	}
+	utc, err := time.Parse(DateTimeLayout, t.UtcTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid utcTime %q: %v", t.UtcTime, err)
+	}
+	// The two readings may straddle a second boundary; offsets are
+	// whole quarter hours.
+	offset := local.Sub(utc).Round(15 * time.Minute)
+	return time.FixedZone(offsetName(offset), int(offset.Seconds())), nil
+}
+
+// offsetName returns the name of a fixed zone offset from UTC, e.g.
+// "UTC-03:30".
+func offsetName(offset time.Duration) string {
+	sign, m := '+', int(offset/time.Minute)
+	if m < 0 {
+		sign, m = '-', -m
+	}
+	return fmt.Sprintf("UTC%c%02d:%02d", sign, m/60, m%60)
+}
+
+// ParseDateTime parses an API date ("2006-01-02") and time
+// ("15:04:05") in loc.  An empty time is midnight.
+func ParseDateTime(date, clock string, loc *time.Location) (time.Time, error) {
+	if clock == "" {
+		clock = "00:00:00"
+	}
+	return time.ParseInLocation(DateTimeLayout, strings.TrimSpace(date)+" "+strings.TrimSpace(clock), loc)
+}
+
+// FormatDateTime returns the API date and time strings for t in loc.
+func FormatDateTime(t time.Time, loc *time.Location) (date, clock string) {
+	t = t.In(loc)
+	return t.Format(DateLayout), t.Format(TimeLayout)
+}
+
+// Start returns the start of the event.  loc is the thermostat's time
+// zone, see Thermostat.TimeZone.
+func (e *Event) Start(loc *time.Location) (time.Time, error) {
+	return ParseDateTime(e.StartDate, e.StartTime, loc)
+}
+
+// End returns the end of the event.  loc is the thermostat's time
+// zone, see Thermostat.TimeZone.
+func (e *Event) End(loc *time.Location) (time.Time, error) {
+	return ParseDateTime(e.EndDate, e.EndTime, loc)
+}
+
+// ThermostatTimeZone returns the time zone of the thermostats matched
+// by ids, a comma separated list of identifiers.  Results are cached
+// for the life of the Client.
+func (c *Client) ThermostatTimeZone(ctx context.Context, ids string) (*time.Location, error) {
+	c.zoneMu.Lock()
+	loc, ok := c.zones[ids]
+	c.zoneMu.Unlock()
+	if ok {
+		return loc, nil
+	}
+
+	ts, err := c.GetThermostatsContext(ctx, Selection{
+		SelectionType:   SelectionTypeThermostats,
+		SelectionMatch:  ids,
+		IncludeLocation: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ts) == 0 {
+		return nil, fmt.Errorf("no thermostats matching %q", ids)
+	}
+	now := time.Now()
+	for i := range ts {
+		l, err := ts[i].TimeZone()
+		if err != nil {
+			return nil, err
+		}
+		if loc == nil {
+			loc = l
+			continue
+		}
+		_, o1 := now.In(loc).Zone()
+		_, o2 := now.In(l).Zone()
+		if o1 != o2 {
+			return nil, fmt.Errorf("thermostats %s and %s are in different time zones", ts[0].Identifier, ts[i].Identifier)
+		}
+	}
+
+	c.zoneMu.Lock()
+	if c.zones == nil {
+		c.zones = map[string]*time.Location{}
+	}
+	c.zones[ids] = loc
+	c.zoneMu.Unlock()
+	return loc, nil
+}
+
+// ThermostatTimeZones returns the time zones of the thermostats ids, a
+// comma separated list of at most 25 identifiers, by identifier.  The
+// zones not cached yet are fetched with a single request.
+func (c *Client) ThermostatTimeZones(ctx context.Context, ids string) (map[string]*time.Location, error) {
+	zones := map[string]*time.Location{}
+	var missing []string
+	c.zoneMu.Lock()
+	for _, id := range strings.Split(ids, ",") {
+		if loc, ok := c.zones[id]; ok {
+			zones[id] = loc
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	c.zoneMu.Unlock()
+	if len(missing) == 0 {
+		return zones, nil
+	}
+
+	ts, err := c.GetThermostatsContext(ctx, Selection{
+		SelectionType:   SelectionTypeThermostats,
+		SelectionMatch:  strings.Join(missing, ","),
+		IncludeLocation: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range ts {
+		loc, err := ts[i].TimeZone()
+		if err != nil {
+			return nil, err
+		}
+		zones[ts[i].Identifier] = loc
+	}
+	for _, id := range missing {
+		if zones[id] == nil {
+			return nil, fmt.Errorf("no thermostat %s", id)
+		}
+	}
+
+	c.zoneMu.Lock()
+	if c.zones == nil {
+		c.zones = map[string]*time.Location{}
+	}
+	for _, id := range missing {
+		c.zones[id] = zones[id]
+	}
+	c.zoneMu.Unlock()
+	return zones, nil
+}
@@ -0,0 +1,76 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+func TestTimeZoneOffset(t *testing.T) {
+	for _, tc := range []struct {
+		local, utc string
+		wantName   string
+		wantOffset int
+	}{
+		{"2017-12-01 12:00:00", "2017-12-01 12:00:00", "UTC+00:00", 0},
+		{"2017-12-01 07:00:00", "2017-12-01 12:00:00", "UTC-05:00", -5 * 3600},
+		{"2017-12-01 08:29:59", "2017-12-01 12:00:00", "UTC-03:30", -(3*3600 + 1800)},
+		{"2017-12-01 11:30:00", "2017-12-01 12:00:00", "UTC-00:30", -1800},
+		{"2017-12-01 12:30:01", "2017-12-01 12:00:00", "UTC+00:30", 1800},
+		{"2017-12-02 05:45:00", "2017-12-01 12:00:00", "UTC+17:45", 17*3600 + 2700},
+		{"2017-12-01 00:15:00", "2017-12-01 23:30:00", "UTC-23:15", -(23*3600 + 900)},
+	} {
+		th := ecobee.Thermostat{Identifier: "123", ThermostatTime: tc.local, UtcTime: tc.utc}
+		loc, err := th.TimeZone()
+		if err != nil {
+			t.Errorf("TimeZone(%s, %s): %v", tc.local, tc.utc, err)
+			continue
+		}
+		name, offset := time.Date(2017, 12, 1, 12, 0, 0, 0, loc).Zone()
+		if name != tc.wantName || offset != tc.wantOffset {
+			t.Errorf("TimeZone(%s, %s) = %s %d, want %s %d", tc.local, tc.utc, name, offset, tc.wantName, tc.wantOffset)
+		}
+	}
+}
+
+func TestTimeZone(t *testing.T) {
+	// The named zone wins over the offset, and knows about DST.
+	th := ecobee.Thermostat{
+		Identifier:     "123",
+		Location:       ecobee.Location{TimeZone: "America/Toronto"},
+		ThermostatTime: "2017-12-01 07:00:00",
+		UtcTime:        "2017-12-01 12:00:00",
+	}
+	loc, err := th.TimeZone()
+	if err != nil {
+		t.Fatalf("TimeZone: %v", err)
+	}
+	if name, _ := time.Date(2017, 7, 1, 12, 0, 0, 0, loc).Zone(); name != "EDT" {
+		t.Errorf("July zone = %s, want EDT", name)
+	}
+
+	for _, th := range []ecobee.Thermostat{
+		{Identifier: "123"},
+		{Identifier: "123", ThermostatTime: "2017-12-01 07:00:00"},
+		{Identifier: "123", ThermostatTime: "7am", UtcTime: "2017-12-01 12:00:00"},
+	} {
+		if loc, err := th.TimeZone(); err == nil {
+			t.Errorf("TimeZone(%+v) = %v, want error", th, loc)
+		}
+	}
+}
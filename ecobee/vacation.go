@@ -0,0 +1,166 @@
+package ecobee
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Vacation describes a vacation event for CreateVacation.
+type Vacation struct {
+	Name string
+	// Start and End bound the vacation.  They are converted to the
+	// thermostat's time zone.  A zero Start starts the vacation
+	// immediately.
+	Start, End   time.Time
+	HeatHoldTemp Temperature
+	CoolHoldTemp Temperature
+	// Fan is the fan mode during the vacation, "auto" (the default)
+	// or "on".
+	Fan string
+	// FanMinOnTime is the minimum number of minutes per hour to run
+	// the fan.
+	FanMinOnTime int
+}
+
+// CreateVacationParams are the parameters of the createVacation
+// function.
+type CreateVacationParams struct {
+	Name         string      `json:"name"`
+	CoolHoldTemp Temperature `json:"coolHoldTemp"`
+	HeatHoldTemp Temperature `json:"heatHoldTemp"`
+	StartDate    string      `json:"startDate,omitempty"`
+	StartTime    string      `json:"startTime,omitempty"`
+	EndDate      string      `json:"endDate"`
+	EndTime      string      `json:"endTime"`
+	Fan          string      `json:"fan,omitempty"`
+	FanMinOnTime int         `json:"fanMinOnTime,omitempty"`
+}
+
+// DeleteVacationParams are the parameters of the deleteVacation
+// function.
+type DeleteVacationParams struct {
+	Name string `json:"name"`
+}
+
+// GetVacations fetches the vacation events of a single thermostat.
+func (c *Client) GetVacations(thermostat string) ([]Event, error) {
+	return c.GetVacationsContext(context.Background(), thermostat)
+}
+
+// GetVacationsContext is like GetVacations, using ctx for the request.
+func (c *Client) GetVacationsContext(ctx context.Context, thermostat string) ([]Event, error) {
+	ts, err := c.GetThermostatsContext(ctx, Selection{
+		SelectionType:  SelectionTypeThermostats,
+		SelectionMatch: thermostat,
+		IncludeEvents:  true,
+	})
+	if err != nil {
+		return nil, err
+	} else if len(ts) != 1 {
+		return nil, fmt.Errorf("got %d thermostats, wanted 1", len(ts))
+	}
+	var vs []Event
+	for _, e := range ts[0].Events {
+		if e.Type == "vacation" {
+			vs = append(vs, e)
+		}
+	}
+	return vs, nil
+}
+
+// CreateVacation creates vacation v on the thermostats matched by
+// thermostat.
+func (c *Client) CreateVacation(thermostat string, v Vacation) error {
+	return c.CreateVacationContext(context.Background(), thermostat, v)
+}
+
+// CreateVacationContext is like CreateVacation, using ctx for the
+// request.
+func (c *Client) CreateVacationContext(ctx context.Context, thermostat string, v Vacation) error {
+	if v.Name == "" {
+		return fmt.Errorf("vacation name must not be empty")
+	}
+	if !v.Start.IsZero() && !v.End.After(v.Start) {
+		return fmt.Errorf("vacation must end after it starts")
+	}
+	if !v.End.After(time.Now()) {
+		return fmt.Errorf("vacation must end in the future")
+	}
+	switch v.Fan {
+	case "", "auto", "on":
+	default:
+		return fmt.Errorf("invalid fan mode %q, want auto or on", v.Fan)
+	}
+	if err := tempCheck(v.HeatHoldTemp, v.CoolHoldTemp); err != nil {
+		return err
+	}
+
+	loc, err := c.ThermostatTimeZone(ctx, thermostat)
+	if err != nil {
+		return fmt.Errorf("error determining time zone: %w", err)
+	}
+	p := CreateVacationParams{
+		Name:         v.Name,
+		CoolHoldTemp: v.CoolHoldTemp,
+		HeatHoldTemp: v.HeatHoldTemp,
+		Fan:          v.Fan,
+		FanMinOnTime: v.FanMinOnTime,
+	}
+	if !v.Start.IsZero() {
+		p.StartDate, p.StartTime = FormatDateTime(v.Start, loc)
+	}
+	p.EndDate, p.EndTime = FormatDateTime(v.End, loc)
+
+	r := &UpdateThermostatRequest{
+		Selection: Selection{
+			SelectionType:  SelectionTypeThermostats,
+			SelectionMatch: thermostat,
+		},
+		Functions: []Function{
+			{
+				Type:   "createVacation",
+				Params: p,
+			},
+		},
+	}
+	return c.UpdateThermostatContext(ctx, *r)
+}
+
+// DeleteVacation deletes the vacation called name from the thermostats
+// matched by thermostat.
+func (c *Client) DeleteVacation(thermostat, name string) error {
+	return c.DeleteVacationContext(context.Background(), thermostat, name)
+}
+
+// DeleteVacationContext is like DeleteVacation, using ctx for the
+// request.
+func (c *Client) DeleteVacationContext(ctx context.Context, thermostat, name string) error {
+	r := &UpdateThermostatRequest{
+		Selection: Selection{
+			SelectionType:  SelectionTypeThermostats,
+			SelectionMatch: thermostat,
+		},
+		Functions: []Function{
+			{
+				Type:   "deleteVacation",
+				Params: DeleteVacationParams{Name: name},
+			},
+		},
+	}
+	return c.UpdateThermostatContext(ctx, *r)
+}
@@ -0,0 +1,97 @@
+package ecobee_test
+
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rspier/go-ecobee/ecobee"
+)
+
+func TestCreateVacation(t *testing.T) {
+	s, c, _ := newRetryServer(t)
+
+	// Times are sent in the thermostat's time zone, Toronto.
+	v := ecobee.Vacation{
+		Name:         "Holidays",
+		Start:        time.Date(2099, 12, 24, 20, 0, 0, 0, time.UTC),
+		End:          time.Date(2100, 1, 2, 17, 30, 0, 0, time.UTC),
+		HeatHoldTemp: 620,
+		CoolHoldTemp: 800,
+		FanMinOnTime: 5,
+	}
+	if err := c.CreateVacation("123", v); err != nil {
+		t.Fatalf("CreateVacation: %v", err)
+	}
+	vs, err := c.GetVacations("123")
+	if err != nil {
+		t.Fatalf("GetVacations: %v", err)
+	}
+	if len(vs) != 1 {
+		t.Fatalf("GetVacations = %+v, want one vacation", vs)
+	}
+	e := vs[0]
+	if e.Name != "Holidays" || e.StartDate != "2099-12-24" || e.StartTime != "15:00:00" || e.EndDate != "2100-01-02" || e.EndTime != "12:30:00" {
+		t.Errorf("vacation %q from %s %s to %s %s, want Holidays from 2099-12-24 15:00:00 to 2100-01-02 12:30:00",
+			e.Name, e.StartDate, e.StartTime, e.EndDate, e.EndTime)
+	}
+	if e.HeatHoldTemp != 620 || e.CoolHoldTemp != 800 || e.Fan != "auto" || e.FanMinOnTime != 5 {
+		t.Errorf("vacation holds %s-%s, fan %s %d, want 620-800, fan auto 5", e.HeatHoldTemp, e.CoolHoldTemp, e.Fan, e.FanMinOnTime)
+	}
+
+	if err := c.CreateVacation("123", v); err == nil {
+		t.Error("CreateVacation of an existing name succeeded")
+	}
+	if err := c.DeleteVacation("123", "Holidays"); err != nil {
+		t.Fatalf("DeleteVacation: %v", err)
+	}
+	if evs := thermostat(t, s, "123").Events; len(evs) != 0 {
+		t.Errorf("events = %+v, want none", evs)
+	}
+	if err := c.DeleteVacation("123", "Holidays"); err == nil {
+		t.Error("DeleteVacation of an unknown vacation succeeded")
+	}
+}
+
+func TestCreateVacationErrors(t *testing.T) {
+	_, c, ct := newRetryServer(t)
+	start := time.Now().Add(24 * time.Hour)
+	end := start.Add(7 * 24 * time.Hour)
+	for _, tc := range []struct {
+		name   string
+		change func(*ecobee.Vacation)
+	}{
+		{"no name", func(v *ecobee.Vacation) { v.Name = "" }},
+		{"end before start", func(v *ecobee.Vacation) { v.End = start.Add(-time.Hour) }},
+		{"end at start", func(v *ecobee.Vacation) { v.End = start }},
+		{"end in the past", func(v *ecobee.Vacation) { v.Start, v.End = time.Time{}, time.Now().Add(-time.Hour) }},
+		{"bad fan", func(v *ecobee.Vacation) { v.Fan = "circulate" }},
+		{"no heat", func(v *ecobee.Vacation) { v.HeatHoldTemp = 0 }},
+		{"no cool", func(v *ecobee.Vacation) { v.CoolHoldTemp = 0 }},
+		{"heat too high", func(v *ecobee.Vacation) { v.HeatHoldTemp, v.CoolHoldTemp = 910, 920 }},
+		{"cool too low", func(v *ecobee.Vacation) { v.HeatHoldTemp, v.CoolHoldTemp = 500, 590 }},
+		{"heat above cool", func(v *ecobee.Vacation) { v.HeatHoldTemp, v.CoolHoldTemp = 750, 700 }},
+	} {
+		v := ecobee.Vacation{Name: "Away", Start: start, End: end, HeatHoldTemp: 620, CoolHoldTemp: 800}
+		tc.change(&v)
+		if err := c.CreateVacation("123", v); err == nil {
+			t.Errorf("%s: CreateVacation succeeded", tc.name)
+		}
+	}
+	if n := ct.count("/1/thermostat"); n != 0 {
+		t.Errorf("invalid vacations sent %d requests", n)
+	}
+}